@@ -4,12 +4,15 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/Masterminds/semver"
+	"github.com/weaveworks/flagger/pkg/admission"
 	clientset "github.com/weaveworks/flagger/pkg/client/clientset/versioned"
 	informers "github.com/weaveworks/flagger/pkg/client/informers/externalversions"
 	"github.com/weaveworks/flagger/pkg/controller"
@@ -36,6 +39,7 @@ var (
 	masterURL                string
 	kubeconfig               string
 	metricsServer            string
+	metricsServerCredentials string
 	controlLoopInterval      time.Duration
 	logLevel                 string
 	port                     string
@@ -52,6 +56,9 @@ var (
 	ingressAnnotationsPrefix string
 	enableLeaderElection     bool
 	leaderElectionNamespace  string
+	admissionPort            string
+	admissionCertFile        string
+	admissionKeyFile         string
 	ver                      bool
 )
 
@@ -59,6 +66,7 @@ func init() {
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
 	flag.StringVar(&masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
 	flag.StringVar(&metricsServer, "metrics-server", "http://prometheus:9090", "Prometheus URL.")
+	flag.StringVar(&metricsServerCredentials, "metrics-server-credentials-dir", "", "Path to a directory with the Prometheus credentials files: prometheus_username, prometheus_password, prometheus_token, ca.crt, tls.crt, tls.key and insecure_skip_verify.")
 	flag.DurationVar(&controlLoopInterval, "control-loop-interval", 10*time.Second, "Kubernetes API sync interval.")
 	flag.StringVar(&logLevel, "log-level", "debug", "Log level can be: debug, info, warning, error.")
 	flag.StringVar(&port, "port", "8080", "Port to listen on.")
@@ -75,6 +83,9 @@ func init() {
 	flag.StringVar(&ingressAnnotationsPrefix, "ingress-annotations-prefix", "nginx.ingress.kubernetes.io", "Annotations prefix for ingresses.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false, "Enable leader election.")
 	flag.StringVar(&leaderElectionNamespace, "leader-election-namespace", "kube-system", "Namespace used to create the leader election config map.")
+	flag.StringVar(&admissionPort, "admission-port", "", "Port to listen on for admission review requests, the admission server is disabled when empty.")
+	flag.StringVar(&admissionCertFile, "admission-tls-cert", "/etc/flagger/tls/tls.crt", "Path to the admission server TLS certificate.")
+	flag.StringVar(&admissionKeyFile, "admission-tls-key", "/etc/flagger/tls/tls.key", "Path to the admission server TLS key.")
 	flag.BoolVar(&ver, "version", false, "Print version")
 }
 
@@ -121,6 +132,7 @@ func main() {
 	flaggerInformerFactory := informers.NewSharedInformerFactoryWithOptions(flaggerClient, time.Second*30, informers.WithNamespace(namespace))
 
 	canaryInformer := flaggerInformerFactory.Flagger().V1alpha3().Canaries()
+	metricTemplateInformer := flaggerInformerFactory.Flagger().V1beta1().MetricTemplates()
 
 	logger.Infof("Starting flagger version %s revision %s mesh provider %s", version.VERSION, version.REVISION, meshProvider)
 
@@ -159,7 +171,12 @@ func main() {
 		logger.Infof("Watching namespace %s", namespace)
 	}
 
-	observerFactory, err := metrics.NewFactory(metricsServer, meshProvider, 5*time.Second)
+	credentials, err := readCredentials(metricsServerCredentials)
+	if err != nil {
+		logger.Fatalf("Error reading metrics server credentials: %s", err.Error())
+	}
+
+	observerFactory, err := metrics.NewProviderFactory(metrics.PrometheusProvider, metricsServer, credentials, meshProvider, 5*time.Second)
 	if err != nil {
 		logger.Fatalf("Error building prometheus client: %s", err.Error())
 	}
@@ -177,6 +194,12 @@ func main() {
 	// start HTTP server
 	go server.ListenAndServe(port, 3*time.Second, logger, stopCh)
 
+	// start admission server
+	if admissionPort != "" {
+		admissionHandler := admission.NewHandler(flaggerClient, meshProvider, logger)
+		go admission.ListenAndServeTLS(admissionPort, admissionCertFile, admissionKeyFile, admissionHandler, 3*time.Second, logger, stopCh)
+	}
+
 	routerFactory := router.NewFactory(cfg, kubeClient, flaggerClient, ingressAnnotationsPrefix, logger, meshClient)
 
 	c := controller.NewController(
@@ -184,6 +207,7 @@ func main() {
 		meshClient,
 		flaggerClient,
 		canaryInformer,
+		metricTemplateInformer,
 		controlLoopInterval,
 		logger,
 		notifierClient,
@@ -199,6 +223,7 @@ func main() {
 	logger.Info("Waiting for informer caches to sync")
 	for _, synced := range []cache.InformerSynced{
 		canaryInformer.Informer().HasSynced,
+		metricTemplateInformer.Informer().HasSynced,
 	} {
 		if ok := cache.WaitForCacheSync(stopCh, synced); !ok {
 			logger.Fatalf("Failed to wait for cache sync")
@@ -311,3 +336,35 @@ func fromEnv(envVar string, defaultVal string) string {
 	}
 	return defaultVal
 }
+
+// readCredentials returns the content of the files in the credentials directory keyed by file name,
+// the directory can be a mounted Secret with the same keys as the canary metrics provider Secret,
+// the files are read once at startup and a restart is required to pick up the rotated credentials
+func readCredentials(dir string) (map[string][]byte, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := make(map[string][]byte)
+	for _, file := range files {
+		// skip the hidden entries of the mounted Secret volumes
+		if strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		credentials[file.Name()] = data
+	}
+	return credentials, nil
+}
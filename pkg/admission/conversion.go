@@ -0,0 +1,140 @@
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+	"github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+)
+
+// ConversionReview mirrors the apiextensions.k8s.io/v1beta1 ConversionReview
+// so that the conversion webhook doesn't depend on the apiextensions-apiserver module
+type ConversionReview struct {
+	metav1.TypeMeta `json:",inline"`
+	Request         *ConversionRequest  `json:"request,omitempty"`
+	Response        *ConversionResponse `json:"response,omitempty"`
+}
+
+// ConversionRequest holds the objects to be converted to the desired API version
+type ConversionRequest struct {
+	UID               types.UID              `json:"uid"`
+	DesiredAPIVersion string                 `json:"desiredAPIVersion"`
+	Objects           []runtime.RawExtension `json:"objects"`
+}
+
+// ConversionResponse holds the converted objects in the same order as the request
+type ConversionResponse struct {
+	UID              types.UID              `json:"uid"`
+	ConvertedObjects []runtime.RawExtension `json:"convertedObjects"`
+	Result           metav1.Status          `json:"result"`
+}
+
+// ServeConvert converts canaries between the alpha and v1beta1 API versions
+func (h *Handler) ServeConvert(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("reading the request body failed %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	review := &ConversionReview{}
+	if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+		http.Error(w, fmt.Sprintf("decoding the conversion review failed %v", err), http.StatusBadRequest)
+		return
+	}
+
+	response := &ConversionResponse{
+		UID:    review.Request.UID,
+		Result: metav1.Status{Status: metav1.StatusSuccess},
+	}
+	for _, object := range review.Request.Objects {
+		converted, err := convertCanary(object.Raw, review.Request.DesiredAPIVersion)
+		if err != nil {
+			h.logger.Errorf("Conversion to %s failed %v", review.Request.DesiredAPIVersion, err)
+			response.ConvertedObjects = nil
+			response.Result = metav1.Status{
+				Status:  metav1.StatusFailure,
+				Message: err.Error(),
+			}
+			break
+		}
+		response.ConvertedObjects = append(response.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+
+	review.Request = nil
+	review.Response = response
+
+	data, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("encoding the conversion review failed %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// convertCanary converts the JSON encoded canary to the desired API version,
+// the alpha versions share the same schema and the conversions to and from v1beta1 go through v1alpha3
+func convertCanary(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	meta := &metav1.TypeMeta{}
+	if err := json.Unmarshal(raw, meta); err != nil {
+		return nil, fmt.Errorf("decoding object failed %v", err)
+	}
+
+	if meta.Kind != v1alpha3.CanaryKind {
+		return nil, fmt.Errorf("kind %s is not supported", meta.Kind)
+	}
+
+	if meta.APIVersion == desiredAPIVersion {
+		return raw, nil
+	}
+
+	switch {
+	case isAlphaVersion(meta.APIVersion) && isAlphaVersion(desiredAPIVersion):
+		object := map[string]interface{}{}
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return nil, fmt.Errorf("decoding canary failed %v", err)
+		}
+		object["apiVersion"] = desiredAPIVersion
+		return json.Marshal(object)
+	case isAlphaVersion(meta.APIVersion) && desiredAPIVersion == v1beta1.SchemeGroupVersion.String():
+		src := &v1alpha3.Canary{}
+		if err := json.Unmarshal(raw, src); err != nil {
+			return nil, fmt.Errorf("decoding canary failed %v", err)
+		}
+		dst := &v1beta1.Canary{}
+		dst.ConvertFrom(src)
+		return json.Marshal(dst)
+	case meta.APIVersion == v1beta1.SchemeGroupVersion.String() && isAlphaVersion(desiredAPIVersion):
+		src := &v1beta1.Canary{}
+		if err := json.Unmarshal(raw, src); err != nil {
+			return nil, fmt.Errorf("decoding canary failed %v", err)
+		}
+		dst := &v1alpha3.Canary{}
+		src.ConvertTo(dst)
+		dst.APIVersion = desiredAPIVersion
+		return json.Marshal(dst)
+	}
+
+	return nil, fmt.Errorf("conversion from %s to %s is not supported", meta.APIVersion, desiredAPIVersion)
+}
+
+// isAlphaVersion returns true for the v1alpha1, v1alpha2 and v1alpha3 canary API versions
+func isAlphaVersion(apiVersion string) bool {
+	for _, version := range []string{"v1alpha1", "v1alpha2", "v1alpha3"} {
+		if apiVersion == v1alpha3.SchemeGroupVersion.Group+"/"+version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+	"github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	fakeFlagger "github.com/weaveworks/flagger/pkg/client/clientset/versioned/fake"
+	"github.com/weaveworks/flagger/pkg/logger"
+)
+
+func convert(t *testing.T, handler *Handler, object interface{}, desiredAPIVersion string) *ConversionResponse {
+	raw, err := json.Marshal(object)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	body, err := json.Marshal(ConversionReview{
+		Request: &ConversionRequest{
+			UID:               "test",
+			DesiredAPIVersion: desiredAPIVersion,
+			Objects:           []runtime.RawExtension{{Raw: raw}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	req := httptest.NewRequest("POST", "/convert", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ServeConvert(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Got status %v wanted %v", w.Code, http.StatusOK)
+	}
+
+	result := &ConversionReview{}
+	if err := json.Unmarshal(w.Body.Bytes(), result); err != nil {
+		t.Fatal(err.Error())
+	}
+	return result.Response
+}
+
+func TestHandler_Convert(t *testing.T) {
+	logger, _ := logger.NewLogger("debug")
+	handler := NewHandler(fakeFlagger.NewSimpleClientset(), "istio", logger)
+
+	cd := newTestCanary()
+	cd.Kind = flaggerv1.CanaryKind
+	cd.Spec.Service.MeshName = "global"
+
+	res := convert(t, handler, cd, v1beta1.SchemeGroupVersion.String())
+	if res.Result.Status != "Success" || len(res.ConvertedObjects) != 1 {
+		t.Fatalf("Got result %v with %v objects wanted success", res.Result, len(res.ConvertedObjects))
+	}
+
+	beta := &v1beta1.Canary{}
+	if err := json.Unmarshal(res.ConvertedObjects[0].Raw, beta); err != nil {
+		t.Fatal(err.Error())
+	}
+	if beta.APIVersion != v1beta1.SchemeGroupVersion.String() {
+		t.Errorf("Got API version %v wanted %v", beta.APIVersion, v1beta1.SchemeGroupVersion.String())
+	}
+	if beta.Spec.Service.AppMesh == nil || beta.Spec.Service.AppMesh.MeshName != "global" {
+		t.Errorf("Got App Mesh service %v wanted mesh name %v", beta.Spec.Service.AppMesh, "global")
+	}
+
+	res = convert(t, handler, beta, flaggerv1.SchemeGroupVersion.String())
+	alpha := &flaggerv1.Canary{}
+	if err := json.Unmarshal(res.ConvertedObjects[0].Raw, alpha); err != nil {
+		t.Fatal(err.Error())
+	}
+	if alpha.Spec.Service.MeshName != "global" {
+		t.Errorf("Got mesh name %v wanted %v", alpha.Spec.Service.MeshName, "global")
+	}
+
+	// the alpha versions are converted to v1beta1 through v1alpha3
+	cd.APIVersion = "flagger.app/v1alpha2"
+	res = convert(t, handler, cd, v1beta1.SchemeGroupVersion.String())
+	beta = &v1beta1.Canary{}
+	if err := json.Unmarshal(res.ConvertedObjects[0].Raw, beta); err != nil {
+		t.Fatal(err.Error())
+	}
+	if beta.APIVersion != v1beta1.SchemeGroupVersion.String() || beta.Spec.Service.AppMesh == nil {
+		t.Errorf("Got API version %v App Mesh service %v wanted %v", beta.APIVersion, beta.Spec.Service.AppMesh,
+			v1beta1.SchemeGroupVersion.String())
+	}
+
+	res = convert(t, handler, beta, "flagger.app/v1alpha2")
+	alpha = &flaggerv1.Canary{}
+	if err := json.Unmarshal(res.ConvertedObjects[0].Raw, alpha); err != nil {
+		t.Fatal(err.Error())
+	}
+	if alpha.APIVersion != "flagger.app/v1alpha2" || alpha.Spec.Service.MeshName != "global" {
+		t.Errorf("Got API version %v mesh name %v wanted %v", alpha.APIVersion, alpha.Spec.Service.MeshName,
+			"flagger.app/v1alpha2")
+	}
+
+	// the alpha versions share the same schema
+	res = convert(t, handler, cd, flaggerv1.SchemeGroupVersion.String())
+	alpha = &flaggerv1.Canary{}
+	if err := json.Unmarshal(res.ConvertedObjects[0].Raw, alpha); err != nil {
+		t.Fatal(err.Error())
+	}
+	if alpha.APIVersion != flaggerv1.SchemeGroupVersion.String() || alpha.Spec.Service.MeshName != "global" {
+		t.Errorf("Got API version %v mesh name %v wanted %v", alpha.APIVersion, alpha.Spec.Service.MeshName,
+			flaggerv1.SchemeGroupVersion.String())
+	}
+
+	res = convert(t, handler, cd, "flagger.app/v1")
+	if res.Result.Status != "Failure" {
+		t.Errorf("Got result %v wanted failure", res.Result.Status)
+	}
+}
@@ -0,0 +1,252 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"time"
+
+	"go.uber.org/zap"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+	flaggerv1beta1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	clientset "github.com/weaveworks/flagger/pkg/client/clientset/versioned"
+)
+
+// Handler serves the admission review requests for canaries
+type Handler struct {
+	flaggerClient clientset.Interface
+	meshProvider  string
+	logger        *zap.SugaredLogger
+}
+
+// NewHandler creates an admission handler that uses the flagger client to look up the other canaries,
+// the mesh provider is used to default the canaries that don't specify one
+func NewHandler(flaggerClient clientset.Interface, meshProvider string, logger *zap.SugaredLogger) *Handler {
+	return &Handler{
+		flaggerClient: flaggerClient,
+		meshProvider:  meshProvider,
+		logger:        logger,
+	}
+}
+
+// ServeValidate rejects canaries with an invalid spec
+func (h *Handler) ServeValidate(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, h.validate)
+}
+
+// ServeMutate writes the defaults into the canary spec
+func (h *Handler) ServeMutate(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, h.mutate)
+}
+
+func (h *Handler) serve(w http.ResponseWriter, r *http.Request,
+	admit func(*admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("reading the request body failed %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	review := &admissionv1beta1.AdmissionReview{}
+	if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+		http.Error(w, fmt.Sprintf("decoding the admission review failed %v", err), http.StatusBadRequest)
+		return
+	}
+
+	response := admit(review.Request)
+	response.UID = review.Request.UID
+	review.Response = response
+	review.Request = nil
+
+	data, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("encoding the admission review failed %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (h *Handler) validate(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	if req.Operation != admissionv1beta1.Create && req.Operation != admissionv1beta1.Update {
+		return &admissionv1beta1.AdmissionResponse{Allowed: true}
+	}
+
+	// the v1beta1 strategy is checked before the conversion since v1alpha3 has no strategy
+	var strategyErrs field.ErrorList
+	cd := &flaggerv1.Canary{}
+	if req.Kind.Version == flaggerv1beta1.SchemeGroupVersion.Version {
+		beta := &flaggerv1beta1.Canary{}
+		if err := json.Unmarshal(req.Object.Raw, beta); err != nil {
+			return deny(fmt.Sprintf("decoding canary failed %v", err))
+		}
+		strategyErrs = ValidateStrategy(beta, h.meshProvider)
+		beta.ConvertTo(cd)
+	} else if err := json.Unmarshal(req.Object.Raw, cd); err != nil {
+		return deny(fmt.Sprintf("decoding canary failed %v", err))
+	}
+	if cd.Namespace == "" {
+		cd.Namespace = req.Namespace
+	}
+
+	list, err := h.flaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		h.logger.Errorf("Admission canaries list error %v", err)
+		return deny(fmt.Sprintf("listing canaries in namespace %s failed %v", cd.Namespace, err))
+	}
+
+	// the AlertProviders referenced from other namespaces must allow the canary namespace
+	var providers []flaggerv1beta1.AlertProvider
+	for _, alert := range cd.Spec.CanaryAnalysis.Alerts {
+		ref := alert.ProviderRef
+		if ref.Namespace == "" || ref.Namespace == cd.Namespace {
+			continue
+		}
+		provider, err := h.flaggerClient.FlaggerV1beta1().AlertProviders(ref.Namespace).Get(ref.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			h.logger.Errorf("Admission alert provider get error %v", err)
+			return deny(fmt.Sprintf("getting alert provider %s.%s failed %v", ref.Name, ref.Namespace, err))
+		}
+		providers = append(providers, *provider)
+	}
+
+	errs := append(strategyErrs, ValidateCanary(cd, list.Items, h.meshProvider)...)
+	errs = append(errs, ValidateAlertProviderRefs(cd, providers)...)
+	if len(errs) > 0 {
+		h.logger.With("canary", fmt.Sprintf("%s.%s", cd.Name, cd.Namespace)).
+			Infof("Admission rejected %s", errs.ToAggregate().Error())
+		return deny(errs.ToAggregate().Error())
+	}
+
+	return &admissionv1beta1.AdmissionResponse{Allowed: true}
+}
+
+func (h *Handler) mutate(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	if req.Operation != admissionv1beta1.Create && req.Operation != admissionv1beta1.Update {
+		return &admissionv1beta1.AdmissionResponse{Allowed: true}
+	}
+
+	var spec, defaultedSpec interface{}
+	if req.Kind.Version == flaggerv1beta1.SchemeGroupVersion.Version {
+		cd := &flaggerv1beta1.Canary{}
+		if err := json.Unmarshal(req.Object.Raw, cd); err != nil {
+			return deny(fmt.Sprintf("decoding canary failed %v", err))
+		}
+		spec, defaultedSpec = cd.Spec, setDefaultsV1beta1(cd, h.meshProvider).Spec
+	} else {
+		cd := &flaggerv1.Canary{}
+		if err := json.Unmarshal(req.Object.Raw, cd); err != nil {
+			return deny(fmt.Sprintf("decoding canary failed %v", err))
+		}
+		defaulted := cd.DeepCopy()
+		defaulted.SetDefaults(h.meshProvider)
+		spec, defaultedSpec = cd.Spec, defaulted.Spec
+	}
+
+	if reflect.DeepEqual(spec, defaultedSpec) {
+		return &admissionv1beta1.AdmissionResponse{Allowed: true}
+	}
+
+	patch, err := json.Marshal([]jsonPatchOperation{
+		{
+			Op:    "replace",
+			Path:  "/spec",
+			Value: defaultedSpec,
+		},
+	})
+	if err != nil {
+		return deny(fmt.Sprintf("encoding canary patch failed %v", err))
+	}
+
+	patchType := admissionv1beta1.PatchTypeJSONPatch
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed:   true,
+		Patch:     patch,
+		PatchType: &patchType,
+	}
+}
+
+// setDefaultsV1beta1 returns a copy of the canary with the v1alpha3 defaults,
+// the analysis isn't converted so that the fields contradicting the strategy are left for the validation
+func setDefaultsV1beta1(cd *flaggerv1beta1.Canary, meshProvider string) *flaggerv1beta1.Canary {
+	defaulted := cd.DeepCopy()
+	alpha := &flaggerv1.Canary{
+		Spec: flaggerv1.CanarySpec{
+			Provider:                defaulted.Spec.Provider,
+			ProgressDeadlineSeconds: defaulted.Spec.ProgressDeadlineSeconds,
+			CanaryAnalysis:          defaulted.Spec.CanaryAnalysis.CanaryAnalysis,
+		},
+	}
+	alpha.SetDefaults(meshProvider)
+	defaulted.Spec.ProgressDeadlineSeconds = alpha.Spec.ProgressDeadlineSeconds
+	defaulted.Spec.CanaryAnalysis.CanaryAnalysis = alpha.Spec.CanaryAnalysis
+	return defaulted
+}
+
+// jsonPatchOperation is a RFC 6902 JSON patch operation
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+func deny(message string) *admissionv1beta1.AdmissionResponse {
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonInvalid,
+			Message: message,
+		},
+	}
+}
+
+// ListenAndServeTLS starts the admission web server and waits for SIGTERM
+func ListenAndServeTLS(port string, certFile string, keyFile string, handler *Handler,
+	timeout time.Duration, logger *zap.SugaredLogger, stopCh <-chan struct{}) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/validate", handler.ServeValidate)
+	mux.HandleFunc("/mutate", handler.ServeMutate)
+	mux.HandleFunc("/convert", handler.ServeConvert)
+
+	srv := &http.Server{
+		Addr:         ":" + port,
+		Handler:      mux,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  15 * time.Second,
+	}
+
+	logger.Infof("Starting admission server on port %s", port)
+
+	// run server in background
+	go func() {
+		if err := srv.ListenAndServeTLS(certFile, keyFile); err != http.ErrServerClosed {
+			logger.Fatalf("Admission server crashed %v", err)
+		}
+	}()
+
+	// wait for SIGTERM or SIGINT
+	<-stopCh
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Errorf("Admission server graceful shutdown failed %v", err)
+	} else {
+		logger.Info("Admission server stopped")
+	}
+}
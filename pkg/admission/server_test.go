@@ -0,0 +1,166 @@
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+	flaggerv1beta1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	fakeFlagger "github.com/weaveworks/flagger/pkg/client/clientset/versioned/fake"
+	"github.com/weaveworks/flagger/pkg/logger"
+)
+
+func review(t *testing.T, handler http.HandlerFunc, object interface{}) *admissionv1beta1.AdmissionResponse {
+	raw, err := json.Marshal(object)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// the API server sends the object in the version of the request
+	meta := &metav1.TypeMeta{}
+	if err := json.Unmarshal(raw, meta); err != nil {
+		t.Fatal(err.Error())
+	}
+	gvk := meta.GroupVersionKind()
+
+	body, err := json.Marshal(admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{
+			UID:       "test",
+			Kind:      metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+			Namespace: "default",
+			Operation: admissionv1beta1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	req := httptest.NewRequest("POST", "/validate", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Got status %v wanted %v", w.Code, http.StatusOK)
+	}
+
+	result := &admissionv1beta1.AdmissionReview{}
+	if err := json.Unmarshal(w.Body.Bytes(), result); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if result.Response.UID != "test" {
+		t.Errorf("Got UID %v wanted %v", result.Response.UID, "test")
+	}
+	return result.Response
+}
+
+func TestHandler_Validate(t *testing.T) {
+	logger, _ := logger.NewLogger("debug")
+	existing := newTestCanary()
+	existing.Name = "podinfo-existing"
+	handler := NewHandler(fakeFlagger.NewSimpleClientset(existing), "istio", logger)
+
+	cd := newTestCanary()
+	cd.Spec.TargetRef.Name = "frontend"
+	if res := review(t, handler.ServeValidate, cd); !res.Allowed {
+		t.Errorf("Got allowed %v wanted %v: %v", res.Allowed, true, res.Result)
+	}
+
+	cd = newTestCanary()
+	if res := review(t, handler.ServeValidate, cd); res.Allowed {
+		t.Errorf("Got allowed %v wanted %v", res.Allowed, false)
+	}
+
+	beta := &flaggerv1beta1.Canary{}
+	beta.ConvertFrom(newTestCanary())
+	beta.Spec.TargetRef.Name = "frontend"
+	if res := review(t, handler.ServeValidate, beta); !res.Allowed {
+		t.Errorf("Got allowed %v wanted %v for v1beta1: %v", res.Allowed, true, res.Result)
+	}
+
+	beta.Spec.CanaryAnalysis.Strategy = flaggerv1beta1.CanaryStrategyBlueGreen
+	if res := review(t, handler.ServeValidate, beta); res.Allowed {
+		t.Errorf("Got allowed %v wanted %v for a blue/green strategy without iterations", res.Allowed, false)
+	}
+}
+
+func TestHandler_Mutate(t *testing.T) {
+	logger, _ := logger.NewLogger("debug")
+	handler := NewHandler(fakeFlagger.NewSimpleClientset(), "kubernetes", logger)
+
+	cd := newTestCanary()
+	cd.Spec.CanaryAnalysis.Webhooks[0].Timeout = ""
+	res := review(t, handler.ServeMutate, cd)
+	if !res.Allowed {
+		t.Fatalf("Got allowed %v wanted %v", res.Allowed, true)
+	}
+
+	var patch []struct {
+		Op    string               `json:"op"`
+		Path  string               `json:"path"`
+		Value flaggerv1.CanarySpec `json:"value"`
+	}
+	if err := json.Unmarshal(res.Patch, &patch); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(patch) != 1 || patch[0].Path != "/spec" {
+		t.Fatalf("Got patch %s wanted a /spec replace", res.Patch)
+	}
+
+	spec := patch[0].Value
+	if spec.CanaryAnalysis.Webhooks[0].Timeout != flaggerv1.WebhookTimeout {
+		t.Errorf("Got webhook timeout %v wanted %v", spec.CanaryAnalysis.Webhooks[0].Timeout, flaggerv1.WebhookTimeout)
+	}
+	if spec.CanaryAnalysis.Iterations != flaggerv1.KubernetesIterations {
+		t.Errorf("Got iterations %v wanted %v", spec.CanaryAnalysis.Iterations, flaggerv1.KubernetesIterations)
+	}
+	if *spec.ProgressDeadlineSeconds != flaggerv1.ProgressDeadlineSeconds {
+		t.Errorf("Got progress deadline %v wanted %v", *spec.ProgressDeadlineSeconds, flaggerv1.ProgressDeadlineSeconds)
+	}
+}
+
+func TestHandler_MutateV1beta1(t *testing.T) {
+	logger, _ := logger.NewLogger("debug")
+	handler := NewHandler(fakeFlagger.NewSimpleClientset(), "kubernetes", logger)
+
+	cd := &flaggerv1beta1.Canary{}
+	cd.ConvertFrom(newTestCanary())
+	res := review(t, handler.ServeMutate, cd)
+	if !res.Allowed {
+		t.Fatalf("Got allowed %v wanted %v", res.Allowed, true)
+	}
+
+	var patch []struct {
+		Op    string                    `json:"op"`
+		Path  string                    `json:"path"`
+		Value flaggerv1beta1.CanarySpec `json:"value"`
+	}
+	if err := json.Unmarshal(res.Patch, &patch); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(patch) != 1 || patch[0].Path != "/spec" {
+		t.Fatalf("Got patch %s wanted a /spec replace", res.Patch)
+	}
+
+	// the strategy is kept so that the validation rejects it for the kubernetes provider
+	analysis := patch[0].Value.CanaryAnalysis
+	if analysis.Strategy != flaggerv1beta1.CanaryStrategyCanary {
+		t.Errorf("Got strategy %v wanted %v", analysis.Strategy, flaggerv1beta1.CanaryStrategyCanary)
+	}
+	if analysis.Iterations != flaggerv1.KubernetesIterations {
+		t.Errorf("Got iterations %v wanted %v", analysis.Iterations, flaggerv1.KubernetesIterations)
+	}
+	if analysis.StepWeight != 10 {
+		t.Errorf("Got step weight %v wanted %v", analysis.StepWeight, 10)
+	}
+}
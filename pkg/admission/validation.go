@@ -0,0 +1,554 @@
+package admission
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+	flaggerv1beta1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	"github.com/weaveworks/flagger/pkg/metrics"
+	"github.com/weaveworks/flagger/pkg/router"
+)
+
+// builtinMetrics are the metric checks that don't require a query
+var builtinMetrics = map[string]bool{
+	"request-success-rate":   true,
+	"request-duration":       true,
+	"request-rate":           true,
+	"error-budget-burn-rate": true,
+}
+
+// hookTypes are the webhook types known by the scheduler, an empty type means rollout
+var hookTypes = map[flaggerv1.HookType]bool{
+	"":                             true,
+	flaggerv1.RolloutHook:          true,
+	flaggerv1.PreRolloutHook:       true,
+	flaggerv1.PostRolloutHook:      true,
+	flaggerv1.ConfirmRolloutHook:   true,
+	flaggerv1.ConfirmPromotionHook: true,
+}
+
+// ValidateCanary checks the canary spec, the canaries from the same namespace
+// are used to detect targets that are referenced by more than one canary,
+// the mesh provider is used when the canary doesn't specify one
+func ValidateCanary(cd *flaggerv1.Canary, canaries []flaggerv1.Canary, meshProvider string) field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	provider := meshProvider
+	if cd.Spec.Provider != "" {
+		provider = cd.Spec.Provider
+	}
+
+	if cd.Spec.Provider != "" && !router.IsSupportedProvider(cd.Spec.Provider) {
+		allErrs = append(allErrs, field.NotSupported(specPath.Child("provider"), cd.Spec.Provider,
+			[]string{"kubernetes", "istio", "linkerd", "appmesh", "nginx", "gloo", "smi:<mesh>", "supergloo:<mesh>"}))
+	}
+
+	if p := cd.Spec.MetricsProvider; p != nil {
+		providerPath := specPath.Child("metricsProvider")
+		// the json provider has no builtin metrics and is only supported by the metric templates
+		if !metrics.IsSupportedProvider(p.Type) || p.Type == metrics.JSONProvider {
+			allErrs = append(allErrs, field.NotSupported(providerPath.Child("type"), p.Type,
+				[]string{metrics.PrometheusProvider, metrics.DatadogProvider, metrics.InfluxDBProvider}))
+		}
+		if p.Type == metrics.DatadogProvider && p.SecretRef == nil {
+			allErrs = append(allErrs, field.Required(providerPath.Child("secretRef"),
+				"the Datadog API and application keys are required"))
+		}
+		if p.Type == metrics.InfluxDBProvider {
+			if cd.Spec.MetricsServer == "" {
+				allErrs = append(allErrs, field.Required(specPath.Child("metricsServer"),
+					"the InfluxDB address is required"))
+			}
+			allErrs = append(allErrs, validateInfluxDBAnalysis(cd.Spec.CanaryAnalysis, specPath.Child("canaryAnalysis"))...)
+		}
+	}
+
+	allErrs = append(allErrs, validateTargetRef(cd, canaries, specPath.Child("targetRef"))...)
+
+	if cd.Spec.Service.Timeout != "" {
+		allErrs = append(allErrs, validateDuration(cd.Spec.Service.Timeout, specPath.Child("service", "timeout"))...)
+	}
+
+	allErrs = append(allErrs, validateAnalysis(cd.Spec.CanaryAnalysis, specPath.Child("canaryAnalysis"))...)
+
+	// the builtin metrics of the NGINX and Gloo providers don't distinguish between the primary and the canary
+	if cd.Spec.MetricsProvider == nil && (provider == "nginx" || strings.HasPrefix(provider, "gloo")) {
+		for i, metric := range cd.Spec.CanaryAnalysis.Metrics {
+			if metric.Comparison != nil && metric.Query == "" && metric.TemplateRef == nil {
+				allErrs = append(allErrs, field.Forbidden(specPath.Child("canaryAnalysis", "metrics").Index(i).Child("comparison"),
+					fmt.Sprintf("may not be specified for builtin metrics with the %s provider, use a templateRef instead", provider)))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// ValidateAlertProviderRefs rejects the alerts that reference an AlertProvider from another namespace,
+// unless the provider allows the canary namespace, the providers are the ones found in the referenced namespaces
+func ValidateAlertProviderRefs(cd *flaggerv1.Canary, providers []flaggerv1beta1.AlertProvider) field.ErrorList {
+	var allErrs field.ErrorList
+	alertsPath := field.NewPath("spec").Child("canaryAnalysis", "alerts")
+
+	for i, alert := range cd.Spec.CanaryAnalysis.Alerts {
+		ref := alert.ProviderRef
+		if ref.Namespace == "" || ref.Namespace == cd.Namespace {
+			continue
+		}
+
+		refPath := alertsPath.Index(i).Child("providerRef")
+		var provider *flaggerv1beta1.AlertProvider
+		for j := range providers {
+			if providers[j].Name == ref.Name && providers[j].Namespace == ref.Namespace {
+				provider = &providers[j]
+			}
+		}
+		if provider == nil {
+			allErrs = append(allErrs, field.NotFound(refPath.Child("name"), fmt.Sprintf("%s.%s", ref.Name, ref.Namespace)))
+		} else if !provider.AllowsNamespace(cd.Namespace) {
+			allErrs = append(allErrs, field.Forbidden(refPath.Child("namespace"),
+				fmt.Sprintf("alert provider %s.%s doesn't allow canaries from namespace %s", ref.Name, ref.Namespace, cd.Namespace)))
+		}
+	}
+
+	return allErrs
+}
+
+// ValidateStrategy rejects the v1beta1 analysis fields that contradict the strategy,
+// the canaries are stored as v1alpha3 where the scheduler infers the strategy from these fields
+func ValidateStrategy(cd *flaggerv1beta1.Canary, meshProvider string) field.ErrorList {
+	var allErrs field.ErrorList
+	analysis := cd.Spec.CanaryAnalysis
+	fldPath := field.NewPath("spec").Child("canaryAnalysis")
+
+	provider := meshProvider
+	if cd.Spec.Provider != "" {
+		provider = cd.Spec.Provider
+	}
+
+	// Blue/Green is the only strategy supported by the kubernetes provider
+	if provider == "kubernetes" && analysis.Strategy != "" && analysis.Strategy != flaggerv1beta1.CanaryStrategyBlueGreen {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("strategy"), analysis.Strategy,
+			[]string{string(flaggerv1beta1.CanaryStrategyBlueGreen)}))
+		return allErrs
+	}
+
+	switch analysis.Strategy {
+	case "":
+		// the strategy is inferred from the analysis fields
+	case flaggerv1beta1.CanaryStrategyCanary:
+		if analysis.StepWeight == 0 && len(analysis.StepWeights) == 0 {
+			allErrs = append(allErrs, field.Required(fldPath.Child("stepWeight"),
+				"stepWeight or stepWeights are required by the Canary strategy"))
+		}
+		if analysis.Iterations > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("iterations"),
+				"may not be specified with the Canary strategy"))
+		}
+		if len(analysis.Match) > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("match"),
+				"may not be specified with the Canary strategy"))
+		}
+	case flaggerv1beta1.CanaryStrategyBlueGreen, flaggerv1beta1.CanaryStrategyABTesting:
+		strategy := string(analysis.Strategy)
+		if analysis.Iterations < 1 {
+			allErrs = append(allErrs, field.Required(fldPath.Child("iterations"),
+				fmt.Sprintf("iterations are required by the %s strategy", strategy)))
+		}
+		if analysis.Strategy == flaggerv1beta1.CanaryStrategyABTesting && len(analysis.Match) == 0 {
+			allErrs = append(allErrs, field.Required(fldPath.Child("match"),
+				"match conditions are required by the ABTesting strategy"))
+		}
+		if analysis.Strategy == flaggerv1beta1.CanaryStrategyBlueGreen && len(analysis.Match) > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("match"),
+				"may not be specified with the BlueGreen strategy"))
+		}
+		if analysis.StepWeight > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("stepWeight"),
+				fmt.Sprintf("may not be specified with the %s strategy", strategy)))
+		}
+		if len(analysis.StepWeights) > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("stepWeights"),
+				fmt.Sprintf("may not be specified with the %s strategy", strategy)))
+		}
+		if analysis.MaxWeight > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("maxWeight"),
+				fmt.Sprintf("may not be specified with the %s strategy", strategy)))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("strategy"), analysis.Strategy,
+			[]string{string(flaggerv1beta1.CanaryStrategyCanary), string(flaggerv1beta1.CanaryStrategyBlueGreen),
+				string(flaggerv1beta1.CanaryStrategyABTesting)}))
+	}
+
+	return allErrs
+}
+
+func validateTargetRef(cd *flaggerv1.Canary, canaries []flaggerv1.Canary, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if cd.Spec.TargetRef.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), ""))
+		return allErrs
+	}
+
+	switch cd.Spec.TargetRef.Kind {
+	case "", "Deployment", "DaemonSet", "StatefulSet":
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("kind"), cd.Spec.TargetRef.Kind,
+			[]string{"Deployment", "DaemonSet", "StatefulSet"}))
+	}
+
+	for _, canary := range canaries {
+		if canary.Name == cd.Name || canary.Namespace != cd.Namespace {
+			continue
+		}
+		if canary.Spec.TargetRef.Name == cd.Spec.TargetRef.Name &&
+			targetKind(canary) == targetKind(*cd) {
+			allErrs = append(allErrs, field.Duplicate(fldPath,
+				fmt.Sprintf("%s %s is already targeted by canary %s", targetKind(*cd), cd.Spec.TargetRef.Name, canary.Name)))
+		}
+	}
+
+	return allErrs
+}
+
+func validateAnalysis(analysis flaggerv1.CanaryAnalysis, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if analysis.Interval != "" {
+		allErrs = append(allErrs, validateDuration(analysis.Interval, fldPath.Child("interval"))...)
+	}
+
+	if analysis.MaxWeight < 0 || analysis.MaxWeight > 100 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxWeight"), analysis.MaxWeight,
+			"must be between 0 and 100"))
+	}
+
+	maxWeight := analysis.MaxWeight
+	if maxWeight == 0 {
+		maxWeight = 100
+	}
+	if analysis.StepWeight < 0 || analysis.StepWeight > maxWeight {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("stepWeight"), analysis.StepWeight,
+			fmt.Sprintf("must be between 0 and maxWeight %d", maxWeight)))
+	}
+
+	if len(analysis.StepWeights) > 0 {
+		if analysis.StepWeight > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("stepWeights"),
+				"may not be specified when stepWeight is set"))
+		}
+		previous := 0
+		for i, weight := range analysis.StepWeights {
+			if weight <= previous || weight > 100 {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("stepWeights").Index(i), weight,
+					"must be between 1 and 100 and greater than the previous step"))
+			}
+			previous = weight
+		}
+	}
+
+	if analysis.StepInterval != "" {
+		allErrs = append(allErrs, validateDuration(analysis.StepInterval, fldPath.Child("stepInterval"))...)
+	}
+	if analysis.MaxDuration != "" {
+		allErrs = append(allErrs, validateDuration(analysis.MaxDuration, fldPath.Child("maxDuration"))...)
+	}
+	switch analysis.MaxDurationAction {
+	case "", flaggerv1.MaxDurationRollback, flaggerv1.MaxDurationPromote:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("maxDurationAction"), analysis.MaxDurationAction,
+			[]string{string(flaggerv1.MaxDurationRollback), string(flaggerv1.MaxDurationPromote)}))
+	}
+	if analysis.StepChecks < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("stepChecks"), analysis.StepChecks,
+			"must be greater than or equal to 0"))
+	}
+
+	for i, metric := range analysis.Metrics {
+		metricPath := fldPath.Child("metrics").Index(i)
+		if metric.Name == "" {
+			allErrs = append(allErrs, field.Required(metricPath.Child("name"), ""))
+		}
+		if metric.TemplateRef != nil {
+			if metric.Query != "" {
+				allErrs = append(allErrs, field.Forbidden(metricPath.Child("query"),
+					"may not be specified together with templateRef"))
+			}
+			if metric.TemplateRef.Name == "" {
+				allErrs = append(allErrs, field.Required(metricPath.Child("templateRef", "name"), ""))
+			}
+		} else if metric.Query == "" && !builtinMetrics[metric.Name] {
+			allErrs = append(allErrs, field.Invalid(metricPath.Child("name"), metric.Name,
+				"unknown builtin metric, a query or a templateRef is required for custom metrics"))
+		}
+		if metric.Interval != "" {
+			allErrs = append(allErrs, validateDuration(metric.Interval, metricPath.Child("interval"))...)
+		}
+		if analysis.Judge != nil && metric.Query != "" {
+			allErrs = append(allErrs, field.Forbidden(metricPath.Child("query"),
+				"may not be specified when the judge is set, use a templateRef instead"))
+		}
+		if cmp := metric.Comparison; cmp != nil {
+			comparisonPath := metricPath.Child("comparison")
+			if metric.Query != "" {
+				allErrs = append(allErrs, field.Forbidden(comparisonPath,
+					"may not be specified for custom queries, use a templateRef instead"))
+			}
+			if metric.Name == "request-rate" && metric.Query == "" && metric.TemplateRef == nil {
+				allErrs = append(allErrs, field.Forbidden(comparisonPath,
+					"may not be specified for the builtin request-rate, the primary and the canary receive different traffic"))
+			}
+			if metric.Threshold != 0 || metric.ThresholdRange != nil {
+				allErrs = append(allErrs, field.Forbidden(comparisonPath,
+					"may not be specified together with threshold or thresholdRange"))
+			}
+			if cmp.MaxDeviationPercent == nil && cmp.MaxDelta == nil {
+				allErrs = append(allErrs, field.Required(comparisonPath, "maxDeviationPercent or maxDelta is required"))
+			}
+			if cmp.MaxDeviationPercent != nil && *cmp.MaxDeviationPercent < 0 {
+				allErrs = append(allErrs, field.Invalid(comparisonPath.Child("maxDeviationPercent"), *cmp.MaxDeviationPercent,
+					"must be greater than or equal to 0"))
+			}
+			if cmp.MaxDelta != nil && *cmp.MaxDelta < 0 {
+				allErrs = append(allErrs, field.Invalid(comparisonPath.Child("maxDelta"), *cmp.MaxDelta,
+					"must be greater than or equal to 0"))
+			}
+		}
+		if r := metric.ThresholdRange; r != nil {
+			rangePath := metricPath.Child("thresholdRange")
+			if metric.Threshold != 0 {
+				allErrs = append(allErrs, field.Forbidden(rangePath, "may not be specified together with threshold"))
+			}
+			if r.Min == nil && r.Max == nil {
+				allErrs = append(allErrs, field.Required(rangePath, "min or max is required"))
+			} else if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+				allErrs = append(allErrs, field.Invalid(rangePath.Child("max"), *r.Max,
+					fmt.Sprintf("must be greater than or equal to min %v", *r.Min)))
+			}
+		}
+		if metric.Aggregation != nil {
+			allErrs = append(allErrs, validateAggregation(metric, metricPath.Child("aggregation"))...)
+		}
+		allErrs = append(allErrs, validateBuiltinOptions(metric, metricPath)...)
+	}
+
+	names := make(map[string]bool)
+	for i, webhook := range analysis.Webhooks {
+		webhookPath := fldPath.Child("webhooks").Index(i)
+		if webhook.Name == "" {
+			allErrs = append(allErrs, field.Required(webhookPath.Child("name"), ""))
+		} else if names[webhook.Name] {
+			allErrs = append(allErrs, field.Duplicate(webhookPath.Child("name"), webhook.Name))
+		}
+		names[webhook.Name] = true
+
+		if !hookTypes[webhook.Type] {
+			allErrs = append(allErrs, field.NotSupported(webhookPath.Child("type"), webhook.Type,
+				[]string{string(flaggerv1.RolloutHook), string(flaggerv1.PreRolloutHook), string(flaggerv1.PostRolloutHook),
+					string(flaggerv1.ConfirmRolloutHook), string(flaggerv1.ConfirmPromotionHook)}))
+		}
+
+		if u, err := url.Parse(webhook.URL); err != nil || u.Host == "" ||
+			(u.Scheme != "http" && u.Scheme != "https") {
+			allErrs = append(allErrs, field.Invalid(webhookPath.Child("url"), webhook.URL,
+				"must be an absolute HTTP or HTTPS URL"))
+		}
+
+		if webhook.Timeout != "" {
+			allErrs = append(allErrs, validateDuration(webhook.Timeout, webhookPath.Child("timeout"))...)
+		}
+	}
+
+	for i, alert := range analysis.Alerts {
+		alertPath := fldPath.Child("alerts").Index(i)
+		if alert.Name == "" {
+			allErrs = append(allErrs, field.Required(alertPath.Child("name"), ""))
+		}
+		if alert.Severity != "" && alert.Severity != flaggerv1.AlertSeverityInfo && alert.Severity != flaggerv1.AlertSeverityError {
+			allErrs = append(allErrs, field.NotSupported(alertPath.Child("severity"), alert.Severity,
+				[]string{string(flaggerv1.AlertSeverityInfo), string(flaggerv1.AlertSeverityError)}))
+		}
+		if alert.ProviderRef.Name == "" {
+			allErrs = append(allErrs, field.Required(alertPath.Child("providerRef", "name"), ""))
+		}
+	}
+
+	if analysis.Schedule != nil {
+		allErrs = append(allErrs, validateSchedule(analysis.Schedule, fldPath.Child("schedule"))...)
+	}
+
+	if analysis.Judge != nil {
+		allErrs = append(allErrs, validateJudge(analysis.Judge, fldPath.Child("judge"))...)
+	}
+
+	return allErrs
+}
+
+// validateInfluxDBAnalysis rejects the checks that require the Prometheus or Datadog builtin queries or range queries
+func validateInfluxDBAnalysis(analysis flaggerv1.CanaryAnalysis, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, metric := range analysis.Metrics {
+		if metric.Query == "" && metric.TemplateRef == nil && builtinMetrics[metric.Name] {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("metrics").Index(i).Child("name"), metric.Name,
+				"builtin metrics are not supported by the influxdb provider, a query or a templateRef is required"))
+		}
+	}
+	for i, metric := range analysis.Metrics {
+		if metric.Aggregation != nil && metric.Aggregation.Window != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("metrics").Index(i).Child("aggregation", "window"),
+				"range queries are not supported by the influxdb provider"))
+		}
+	}
+	if analysis.Judge != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("judge"),
+			"may not be specified with the influxdb provider"))
+	}
+
+	return allErrs
+}
+
+// validateBuiltinOptions rejects the percentile and objective fields of the metrics that don't use them
+func validateBuiltinOptions(metric flaggerv1.CanaryMetric, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	builtin := metric.Query == "" && metric.TemplateRef == nil
+
+	if p := metric.Percentile; p != nil {
+		if !builtin || metric.Name != "request-duration" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("percentile"),
+				"may only be specified for the request-duration builtin metric"))
+		} else if *p <= 0 || *p > 100 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("percentile"), *p,
+				"must be greater than 0 and less than or equal to 100"))
+		}
+	}
+
+	if builtin && metric.Name == "error-budget-burn-rate" {
+		if metric.Objective == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("objective"),
+				"the success rate objective is required by the error-budget-burn-rate metric"))
+		} else if *metric.Objective <= 0 || *metric.Objective >= 100 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("objective"), *metric.Objective,
+				"must be greater than 0 and less than 100"))
+		}
+	} else if metric.Objective != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("objective"),
+			"may only be specified for the error-budget-burn-rate builtin metric"))
+	}
+
+	return allErrs
+}
+
+func validateAggregation(metric flaggerv1.CanaryMetric, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	aggregation := metric.Aggregation
+	if metric.Query == "" && metric.TemplateRef == nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath,
+			"may only be specified for custom queries and templateRef metrics"))
+	}
+	if aggregation.Window != "" {
+		allErrs = append(allErrs, validateDuration(aggregation.Window, fldPath.Child("window"))...)
+	}
+	if aggregation.Step != "" {
+		if aggregation.Window == "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("step"), "may not be specified without window"))
+		} else {
+			allErrs = append(allErrs, validateDuration(aggregation.Step, fldPath.Child("step"))...)
+		}
+	}
+	if !metrics.IsSupportedAggregation(aggregation.Function) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("function"), aggregation.Function,
+			[]string{metrics.MinAggregation, metrics.MaxAggregation, metrics.AvgAggregation,
+				metrics.LastAggregation, metrics.PercentileAggregation}))
+	}
+	if aggregation.Function == metrics.PercentileAggregation {
+		if aggregation.Percentile == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("percentile"),
+				"percentile is required by the percentile function"))
+		} else if *aggregation.Percentile < 0 || *aggregation.Percentile > 100 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("percentile"), *aggregation.Percentile,
+				"must be between 0 and 100"))
+		}
+	} else if aggregation.Percentile != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("percentile"),
+			"may only be specified with the percentile function"))
+	}
+	switch aggregation.Series {
+	case "", flaggerv1.CanaryMetricSeriesAny, flaggerv1.CanaryMetricSeriesAggregate:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("series"), aggregation.Series,
+			[]string{string(flaggerv1.CanaryMetricSeriesAny), string(flaggerv1.CanaryMetricSeriesAggregate)}))
+	}
+
+	return allErrs
+}
+
+func validateJudge(judge *flaggerv1.CanaryJudge, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if judge.Threshold < 0 || judge.Threshold > 100 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("threshold"), judge.Threshold,
+			"must be between 0 and 100"))
+	}
+	if judge.Alpha < 0 || judge.Alpha >= 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("alpha"), judge.Alpha,
+			"must be between 0 and 1"))
+	}
+	if judge.Window != "" {
+		allErrs = append(allErrs, validateDuration(judge.Window, fldPath.Child("window"))...)
+	}
+	if judge.Step != "" {
+		allErrs = append(allErrs, validateDuration(judge.Step, fldPath.Child("step"))...)
+	}
+
+	return allErrs
+}
+
+func validateSchedule(schedule *flaggerv1.CanarySchedule, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if _, err := time.LoadLocation(schedule.TimeZone); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("timeZone"), schedule.TimeZone, err.Error()))
+	}
+
+	for i, window := range schedule.Windows {
+		windowPath := fldPath.Child("windows").Index(i)
+		if _, err := cron.ParseStandard(window.Start); err != nil {
+			allErrs = append(allErrs, field.Invalid(windowPath.Child("start"), window.Start, err.Error()))
+		}
+		allErrs = append(allErrs, validateDuration(window.Duration, windowPath.Child("duration"))...)
+	}
+
+	for i, blackout := range schedule.Blackouts {
+		if !blackout.End.After(blackout.Start.Time) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("blackouts").Index(i).Child("end"),
+				blackout.End.String(), "must be after the start"))
+		}
+	}
+
+	return allErrs
+}
+
+func validateDuration(value string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath, value, err.Error()))
+	} else if d <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath, value, "must be greater than zero"))
+	}
+	return allErrs
+}
+
+func targetKind(cd flaggerv1.Canary) string {
+	if cd.Spec.TargetRef.Kind == "" {
+		return "Deployment"
+	}
+	return cd.Spec.TargetRef.Kind
+}
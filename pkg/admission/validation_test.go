@@ -0,0 +1,544 @@
+package admission
+
+import (
+	"strings"
+	"testing"
+
+	hpav1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+	flaggerv1beta1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	istiov1alpha3 "github.com/weaveworks/flagger/pkg/apis/istio/v1alpha3"
+)
+
+func newTestCanary() *flaggerv1.Canary {
+	return &flaggerv1.Canary{
+		TypeMeta: metav1.TypeMeta{APIVersion: flaggerv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "podinfo",
+		},
+		Spec: flaggerv1.CanarySpec{
+			TargetRef: hpav1.CrossVersionObjectReference{
+				Name:       "podinfo",
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+			},
+			Service: flaggerv1.CanaryService{
+				Port: 9898,
+			},
+			CanaryAnalysis: flaggerv1.CanaryAnalysis{
+				Interval:   "1m",
+				Threshold:  10,
+				StepWeight: 10,
+				MaxWeight:  50,
+				Metrics: []flaggerv1.CanaryMetric{
+					{
+						Name:      "request-success-rate",
+						Threshold: 99,
+						Interval:  "1m",
+					},
+					{
+						Name:      "custom",
+						Threshold: 100,
+						Query:     "sum(rate(http_requests_total[1m]))",
+					},
+				},
+				Webhooks: []flaggerv1.CanaryWebhook{
+					{
+						Name:    "load-test",
+						URL:     "http://flagger-loadtester.test/",
+						Timeout: "5s",
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateCanary_Valid(t *testing.T) {
+	if errs := ValidateCanary(newTestCanary(), nil, "istio"); len(errs) > 0 {
+		t.Errorf("Got errors %v wanted none", errs)
+	}
+}
+
+func TestValidateCanary_BuiltinMetrics(t *testing.T) {
+	cd := newTestCanary()
+	percentile, objective := 99.9, 99.5
+	cd.Spec.CanaryAnalysis.Metrics = []flaggerv1.CanaryMetric{
+		{Name: "request-duration", Threshold: 500, Percentile: &percentile},
+		{Name: "request-rate", Threshold: 10},
+		{Name: "error-budget-burn-rate", Threshold: 2, Objective: &objective},
+	}
+	if errs := ValidateCanary(cd, nil, "istio"); len(errs) > 0 {
+		t.Errorf("Got errors %v wanted none", errs)
+	}
+}
+
+func TestValidateCanary_ComparisonProvider(t *testing.T) {
+	cd := newTestCanary()
+	maxDeviation := 5.0
+	cd.Spec.CanaryAnalysis.Metrics = []flaggerv1.CanaryMetric{
+		{Name: "request-success-rate", Comparison: &flaggerv1.CanaryMetricComparison{MaxDeviationPercent: &maxDeviation}},
+	}
+	if errs := ValidateCanary(cd, nil, "nginx"); len(errs) != 1 {
+		t.Errorf("Got %v errors wanted 1 for the nginx mesh provider: %v", len(errs), errs)
+	}
+
+	cd.Spec.CanaryAnalysis.Metrics[0].TemplateRef = &flaggerv1.MetricTemplateRef{Name: "success-rate"}
+	if errs := ValidateCanary(cd, nil, "nginx"); len(errs) > 0 {
+		t.Errorf("Got errors %v wanted none for a metric template", errs)
+	}
+}
+
+func TestValidateCanary_Invalid(t *testing.T) {
+	tests := map[string]struct {
+		mutate func(cd *flaggerv1.Canary)
+		field  string
+	}{
+		"interval": {
+			mutate: func(cd *flaggerv1.Canary) { cd.Spec.CanaryAnalysis.Interval = "1 minute" },
+			field:  "spec.canaryAnalysis.interval",
+		},
+		"step weight": {
+			mutate: func(cd *flaggerv1.Canary) { cd.Spec.CanaryAnalysis.StepWeight = 60 },
+			field:  "spec.canaryAnalysis.stepWeight",
+		},
+		"step weights order": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.CanaryAnalysis.StepWeight = 0
+				cd.Spec.CanaryAnalysis.StepWeights = []int{5, 25, 10}
+			},
+			field: "spec.canaryAnalysis.stepWeights[2]",
+		},
+		"step weights with step weight": {
+			mutate: func(cd *flaggerv1.Canary) { cd.Spec.CanaryAnalysis.StepWeights = []int{5, 25, 50} },
+			field:  "spec.canaryAnalysis.stepWeights",
+		},
+		"step interval": {
+			mutate: func(cd *flaggerv1.Canary) { cd.Spec.CanaryAnalysis.StepInterval = "5" },
+			field:  "spec.canaryAnalysis.stepInterval",
+		},
+		"max duration": {
+			mutate: func(cd *flaggerv1.Canary) { cd.Spec.CanaryAnalysis.MaxDuration = "2 hours" },
+			field:  "spec.canaryAnalysis.maxDuration",
+		},
+		"max duration action": {
+			mutate: func(cd *flaggerv1.Canary) { cd.Spec.CanaryAnalysis.MaxDurationAction = "skip" },
+			field:  "spec.canaryAnalysis.maxDurationAction",
+		},
+		"threshold range": {
+			mutate: func(cd *flaggerv1.Canary) {
+				min, max := 500.0, 50.0
+				cd.Spec.CanaryAnalysis.Metrics[0].Threshold = 0
+				cd.Spec.CanaryAnalysis.Metrics[0].ThresholdRange = &flaggerv1.CanaryThresholdRange{Min: &min, Max: &max}
+			},
+			field: "spec.canaryAnalysis.metrics[0].thresholdRange.max",
+		},
+		"threshold range with threshold": {
+			mutate: func(cd *flaggerv1.Canary) {
+				min := 0.8
+				cd.Spec.CanaryAnalysis.Metrics[0].ThresholdRange = &flaggerv1.CanaryThresholdRange{Min: &min}
+			},
+			field: "spec.canaryAnalysis.metrics[0].thresholdRange",
+		},
+		"comparison": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.CanaryAnalysis.Metrics[0].Threshold = 0
+				cd.Spec.CanaryAnalysis.Metrics[0].Comparison = &flaggerv1.CanaryMetricComparison{}
+			},
+			field: "spec.canaryAnalysis.metrics[0].comparison",
+		},
+		"comparison custom query": {
+			mutate: func(cd *flaggerv1.Canary) {
+				maxDelta := 1.0
+				cd.Spec.CanaryAnalysis.Metrics[1].Threshold = 0
+				cd.Spec.CanaryAnalysis.Metrics[1].Comparison = &flaggerv1.CanaryMetricComparison{MaxDelta: &maxDelta}
+			},
+			field: "spec.canaryAnalysis.metrics[1].comparison",
+		},
+		"comparison request rate": {
+			mutate: func(cd *flaggerv1.Canary) {
+				maxDeviation := 5.0
+				cd.Spec.CanaryAnalysis.Metrics[0].Name = "request-rate"
+				cd.Spec.CanaryAnalysis.Metrics[0].Threshold = 0
+				cd.Spec.CanaryAnalysis.Metrics[0].Comparison = &flaggerv1.CanaryMetricComparison{MaxDeviationPercent: &maxDeviation}
+			},
+			field: "spec.canaryAnalysis.metrics[0].comparison",
+		},
+		"comparison nginx": {
+			mutate: func(cd *flaggerv1.Canary) {
+				maxDeviation := 5.0
+				cd.Spec.Provider = "nginx"
+				cd.Spec.CanaryAnalysis.Metrics[0].Threshold = 0
+				cd.Spec.CanaryAnalysis.Metrics[0].Comparison = &flaggerv1.CanaryMetricComparison{MaxDeviationPercent: &maxDeviation}
+			},
+			field: "spec.canaryAnalysis.metrics[0].comparison",
+		},
+		"comparison gloo": {
+			mutate: func(cd *flaggerv1.Canary) {
+				maxDeviation := 5.0
+				cd.Spec.Provider = "gloo"
+				cd.Spec.CanaryAnalysis.Metrics[0].Threshold = 0
+				cd.Spec.CanaryAnalysis.Metrics[0].Comparison = &flaggerv1.CanaryMetricComparison{MaxDeviationPercent: &maxDeviation}
+			},
+			field: "spec.canaryAnalysis.metrics[0].comparison",
+		},
+		"step checks": {
+			mutate: func(cd *flaggerv1.Canary) { cd.Spec.CanaryAnalysis.StepChecks = -1 },
+			field:  "spec.canaryAnalysis.stepChecks",
+		},
+		"provider": {
+			mutate: func(cd *flaggerv1.Canary) { cd.Spec.Provider = "consul" },
+			field:  "spec.provider",
+		},
+		"metrics provider": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.MetricsProvider = &flaggerv1.CanaryMetricsProvider{Type: "graphite"}
+			},
+			field: "spec.metricsProvider.type",
+		},
+		"metrics provider json": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.MetricsProvider = &flaggerv1.CanaryMetricsProvider{Type: "json"}
+			},
+			field: "spec.metricsProvider.type",
+		},
+		"metrics provider secret": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.MetricsProvider = &flaggerv1.CanaryMetricsProvider{Type: "datadog"}
+			},
+			field: "spec.metricsProvider.secretRef",
+		},
+		"metrics provider address": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.MetricsProvider = &flaggerv1.CanaryMetricsProvider{Type: "influxdb"}
+				cd.Spec.CanaryAnalysis.Metrics = cd.Spec.CanaryAnalysis.Metrics[1:]
+			},
+			field: "spec.metricsServer",
+		},
+		"metrics provider builtin metric": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.MetricsServer = "http://influxdb.monitoring:8086?db=telegraf"
+				cd.Spec.MetricsProvider = &flaggerv1.CanaryMetricsProvider{Type: "influxdb"}
+			},
+			field: "spec.canaryAnalysis.metrics[0].name",
+		},
+		"metrics provider range query": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.MetricsServer = "http://influxdb.monitoring:8086?db=telegraf"
+				cd.Spec.MetricsProvider = &flaggerv1.CanaryMetricsProvider{Type: "influxdb"}
+				cd.Spec.CanaryAnalysis.Metrics = cd.Spec.CanaryAnalysis.Metrics[1:]
+				cd.Spec.CanaryAnalysis.Metrics[0].Aggregation = &flaggerv1.CanaryMetricAggregation{Window: "10m"}
+			},
+			field: "spec.canaryAnalysis.metrics[0].aggregation.window",
+		},
+		"metric": {
+			mutate: func(cd *flaggerv1.Canary) { cd.Spec.CanaryAnalysis.Metrics[0].Name = "request-success" },
+			field:  "spec.canaryAnalysis.metrics[0].name",
+		},
+		"metric template": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.CanaryAnalysis.Metrics[0].TemplateRef = &flaggerv1.MetricTemplateRef{}
+			},
+			field: "spec.canaryAnalysis.metrics[0].templateRef.name",
+		},
+		"metric template query": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.CanaryAnalysis.Metrics[0].Query = "sum(up)"
+				cd.Spec.CanaryAnalysis.Metrics[0].TemplateRef = &flaggerv1.MetricTemplateRef{Name: "up"}
+			},
+			field: "spec.canaryAnalysis.metrics[0].query",
+		},
+		"metric interval": {
+			mutate: func(cd *flaggerv1.Canary) { cd.Spec.CanaryAnalysis.Metrics[0].Interval = "1x" },
+			field:  "spec.canaryAnalysis.metrics[0].interval",
+		},
+		"alert severity": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.CanaryAnalysis.Alerts = []flaggerv1.CanaryAlert{
+					{Name: "on-call", Severity: "warning", ProviderRef: flaggerv1.AlertProviderRef{Name: "slack"}},
+				}
+			},
+			field: "spec.canaryAnalysis.alerts[0].severity",
+		},
+		"alert provider": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.CanaryAnalysis.Alerts = []flaggerv1.CanaryAlert{{Name: "on-call"}}
+			},
+			field: "spec.canaryAnalysis.alerts[0].providerRef.name",
+		},
+		"schedule window": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.CanaryAnalysis.Schedule = &flaggerv1.CanarySchedule{
+					Windows: []flaggerv1.CanaryWindow{{Start: "0 9 * * mon-fri", Duration: "8"}},
+				}
+			},
+			field: "spec.canaryAnalysis.schedule.windows[0].duration",
+		},
+		"schedule time zone": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.CanaryAnalysis.Schedule = &flaggerv1.CanarySchedule{TimeZone: "Europe/Nowhere"}
+			},
+			field: "spec.canaryAnalysis.schedule.timeZone",
+		},
+		"judge custom query": {
+			mutate: func(cd *flaggerv1.Canary) { cd.Spec.CanaryAnalysis.Judge = &flaggerv1.CanaryJudge{Threshold: 80} },
+			field:  "spec.canaryAnalysis.metrics[1].query",
+		},
+		"judge threshold": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.CanaryAnalysis.Metrics = cd.Spec.CanaryAnalysis.Metrics[:1]
+				cd.Spec.CanaryAnalysis.Judge = &flaggerv1.CanaryJudge{Threshold: 120}
+			},
+			field: "spec.canaryAnalysis.judge.threshold",
+		},
+		"judge alpha": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.CanaryAnalysis.Metrics = cd.Spec.CanaryAnalysis.Metrics[:1]
+				cd.Spec.CanaryAnalysis.Judge = &flaggerv1.CanaryJudge{Threshold: 80, Alpha: 1.5}
+			},
+			field: "spec.canaryAnalysis.judge.alpha",
+		},
+		"judge window": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.CanaryAnalysis.Metrics = cd.Spec.CanaryAnalysis.Metrics[:1]
+				cd.Spec.CanaryAnalysis.Judge = &flaggerv1.CanaryJudge{Threshold: 80, Window: "10"}
+			},
+			field: "spec.canaryAnalysis.judge.window",
+		},
+		"aggregation builtin metric": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.CanaryAnalysis.Metrics[0].Aggregation = &flaggerv1.CanaryMetricAggregation{Window: "10m"}
+			},
+			field: "spec.canaryAnalysis.metrics[0].aggregation",
+		},
+		"aggregation step": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.CanaryAnalysis.Metrics[1].Aggregation = &flaggerv1.CanaryMetricAggregation{Step: "30s"}
+			},
+			field: "spec.canaryAnalysis.metrics[1].aggregation.step",
+		},
+		"aggregation function": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.CanaryAnalysis.Metrics[1].Aggregation = &flaggerv1.CanaryMetricAggregation{Window: "10m", Function: "sum"}
+			},
+			field: "spec.canaryAnalysis.metrics[1].aggregation.function",
+		},
+		"aggregation percentile": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.CanaryAnalysis.Metrics[1].Aggregation = &flaggerv1.CanaryMetricAggregation{Function: "percentile"}
+			},
+			field: "spec.canaryAnalysis.metrics[1].aggregation.percentile",
+		},
+		"aggregation series": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.CanaryAnalysis.Metrics[1].Aggregation = &flaggerv1.CanaryMetricAggregation{Series: "all"}
+			},
+			field: "spec.canaryAnalysis.metrics[1].aggregation.series",
+		},
+		"metric percentile": {
+			mutate: func(cd *flaggerv1.Canary) {
+				percentile := 150.0
+				cd.Spec.CanaryAnalysis.Metrics[0] = flaggerv1.CanaryMetric{Name: "request-duration", Threshold: 500, Percentile: &percentile}
+			},
+			field: "spec.canaryAnalysis.metrics[0].percentile",
+		},
+		"metric percentile custom query": {
+			mutate: func(cd *flaggerv1.Canary) {
+				percentile := 95.0
+				cd.Spec.CanaryAnalysis.Metrics[1].Percentile = &percentile
+			},
+			field: "spec.canaryAnalysis.metrics[1].percentile",
+		},
+		"burn rate objective": {
+			mutate: func(cd *flaggerv1.Canary) {
+				cd.Spec.CanaryAnalysis.Metrics[0] = flaggerv1.CanaryMetric{Name: "error-budget-burn-rate", Threshold: 2}
+			},
+			field: "spec.canaryAnalysis.metrics[0].objective",
+		},
+		"burn rate objective range": {
+			mutate: func(cd *flaggerv1.Canary) {
+				objective := 100.0
+				cd.Spec.CanaryAnalysis.Metrics[0] = flaggerv1.CanaryMetric{Name: "error-budget-burn-rate", Threshold: 2, Objective: &objective}
+			},
+			field: "spec.canaryAnalysis.metrics[0].objective",
+		},
+		"objective success rate": {
+			mutate: func(cd *flaggerv1.Canary) {
+				objective := 99.9
+				cd.Spec.CanaryAnalysis.Metrics[0].Objective = &objective
+			},
+			field: "spec.canaryAnalysis.metrics[0].objective",
+		},
+		"webhook url": {
+			mutate: func(cd *flaggerv1.Canary) { cd.Spec.CanaryAnalysis.Webhooks[0].URL = "flagger-loadtester.test" },
+			field:  "spec.canaryAnalysis.webhooks[0].url",
+		},
+		"webhook type": {
+			mutate: func(cd *flaggerv1.Canary) { cd.Spec.CanaryAnalysis.Webhooks[0].Type = "pre" },
+			field:  "spec.canaryAnalysis.webhooks[0].type",
+		},
+		"webhook timeout": {
+			mutate: func(cd *flaggerv1.Canary) { cd.Spec.CanaryAnalysis.Webhooks[0].Timeout = "5" },
+			field:  "spec.canaryAnalysis.webhooks[0].timeout",
+		},
+	}
+
+	for name, test := range tests {
+		cd := newTestCanary()
+		test.mutate(cd)
+		errs := ValidateCanary(cd, nil, "istio")
+		if len(errs) != 1 {
+			t.Errorf("%s: got %v errors wanted 1: %v", name, len(errs), errs)
+			continue
+		}
+		if errs[0].Field != test.field {
+			t.Errorf("%s: got field %s wanted %s", name, errs[0].Field, test.field)
+		}
+	}
+}
+
+func TestValidateCanary_DuplicateTarget(t *testing.T) {
+	cd := newTestCanary()
+	other := newTestCanary()
+	other.Name = "podinfo-2"
+
+	errs := ValidateCanary(cd, []flaggerv1.Canary{*cd, *other}, "istio")
+	if len(errs) != 1 {
+		t.Fatalf("Got %v errors wanted 1: %v", len(errs), errs)
+	}
+
+	if !strings.Contains(errs[0].Error(), "podinfo-2") {
+		t.Errorf("Got error %v wanted a reference to podinfo-2", errs[0])
+	}
+
+	other.Spec.TargetRef.Kind = "DaemonSet"
+	if errs := ValidateCanary(cd, []flaggerv1.Canary{*other}, "istio"); len(errs) > 0 {
+		t.Errorf("Got errors %v wanted none", errs)
+	}
+}
+
+func TestValidateAlertProviderRefs(t *testing.T) {
+	cd := newTestCanary()
+	cd.Spec.CanaryAnalysis.Alerts = []flaggerv1.CanaryAlert{
+		{Name: "team", ProviderRef: flaggerv1.AlertProviderRef{Name: "team"}},
+		{Name: "on-call", ProviderRef: flaggerv1.AlertProviderRef{Name: "on-call", Namespace: "flagger"}},
+	}
+	provider := flaggerv1beta1.AlertProvider{
+		ObjectMeta: metav1.ObjectMeta{Name: "on-call", Namespace: "flagger"},
+		Spec:       flaggerv1beta1.AlertProviderSpec{Type: "slack"},
+	}
+
+	errs := ValidateAlertProviderRefs(cd, []flaggerv1beta1.AlertProvider{provider})
+	if len(errs) != 1 || errs[0].Field != "spec.canaryAnalysis.alerts[1].providerRef.namespace" {
+		t.Errorf("Got errors %v wanted the provider namespace forbidden", errs)
+	}
+
+	if errs := ValidateAlertProviderRefs(cd, nil); len(errs) != 1 || errs[0].Field != "spec.canaryAnalysis.alerts[1].providerRef.name" {
+		t.Errorf("Got errors %v wanted the provider not found", errs)
+	}
+
+	provider.Spec.AllowedNamespaces = []string{"default"}
+	if errs := ValidateAlertProviderRefs(cd, []flaggerv1beta1.AlertProvider{provider}); len(errs) > 0 {
+		t.Errorf("Got errors %v wanted none", errs)
+	}
+}
+
+func TestValidateStrategy(t *testing.T) {
+	match := []istiov1alpha3.HTTPMatchRequest{{}}
+	tests := map[string]struct {
+		mutate func(analysis *flaggerv1beta1.CanaryAnalysis)
+		fields []string
+	}{
+		"canary": {
+			mutate: func(analysis *flaggerv1beta1.CanaryAnalysis) {},
+		},
+		"canary without step weight": {
+			mutate: func(analysis *flaggerv1beta1.CanaryAnalysis) { analysis.StepWeight = 0 },
+			fields: []string{"spec.canaryAnalysis.stepWeight"},
+		},
+		"canary with iterations and match": {
+			mutate: func(analysis *flaggerv1beta1.CanaryAnalysis) {
+				analysis.Iterations = 10
+				analysis.Match = match
+			},
+			fields: []string{"spec.canaryAnalysis.iterations", "spec.canaryAnalysis.match"},
+		},
+		"blue/green": {
+			mutate: func(analysis *flaggerv1beta1.CanaryAnalysis) {
+				analysis.Strategy = flaggerv1beta1.CanaryStrategyBlueGreen
+				analysis.Iterations = 10
+				analysis.StepWeight = 0
+				analysis.MaxWeight = 0
+			},
+		},
+		"blue/green without iterations": {
+			mutate: func(analysis *flaggerv1beta1.CanaryAnalysis) {
+				analysis.Strategy = flaggerv1beta1.CanaryStrategyBlueGreen
+			},
+			fields: []string{"spec.canaryAnalysis.iterations", "spec.canaryAnalysis.stepWeight", "spec.canaryAnalysis.maxWeight"},
+		},
+		"blue/green with match": {
+			mutate: func(analysis *flaggerv1beta1.CanaryAnalysis) {
+				analysis.Strategy = flaggerv1beta1.CanaryStrategyBlueGreen
+				analysis.Iterations = 10
+				analysis.StepWeight = 0
+				analysis.MaxWeight = 0
+				analysis.Match = match
+			},
+			fields: []string{"spec.canaryAnalysis.match"},
+		},
+		"A/B testing": {
+			mutate: func(analysis *flaggerv1beta1.CanaryAnalysis) {
+				analysis.Strategy = flaggerv1beta1.CanaryStrategyABTesting
+				analysis.Iterations = 10
+				analysis.StepWeight = 0
+				analysis.MaxWeight = 0
+				analysis.Match = match
+			},
+		},
+		"A/B testing without match": {
+			mutate: func(analysis *flaggerv1beta1.CanaryAnalysis) {
+				analysis.Strategy = flaggerv1beta1.CanaryStrategyABTesting
+				analysis.Iterations = 10
+				analysis.StepWeight = 0
+				analysis.MaxWeight = 0
+			},
+			fields: []string{"spec.canaryAnalysis.match"},
+		},
+		"inferred": {
+			mutate: func(analysis *flaggerv1beta1.CanaryAnalysis) {
+				analysis.Strategy = ""
+				analysis.Iterations = 10
+			},
+		},
+		"unknown": {
+			mutate: func(analysis *flaggerv1beta1.CanaryAnalysis) { analysis.Strategy = "Shadow" },
+			fields: []string{"spec.canaryAnalysis.strategy"},
+		},
+	}
+
+	for name, test := range tests {
+		cd := &flaggerv1beta1.Canary{}
+		cd.ConvertFrom(newTestCanary())
+		test.mutate(&cd.Spec.CanaryAnalysis)
+		errs := ValidateStrategy(cd, "istio")
+		if len(errs) != len(test.fields) {
+			t.Errorf("%s: got %v errors wanted %v: %v", name, len(errs), len(test.fields), errs)
+			continue
+		}
+		for i := range errs {
+			if errs[i].Field != test.fields[i] {
+				t.Errorf("%s: got field %s wanted %s", name, errs[i].Field, test.fields[i])
+			}
+		}
+	}
+
+	cd := &flaggerv1beta1.Canary{}
+	cd.ConvertFrom(newTestCanary())
+	if errs := ValidateStrategy(cd, "kubernetes"); len(errs) != 1 || errs[0].Field != "spec.canaryAnalysis.strategy" {
+		t.Errorf("Got errors %v wanted the canary strategy not supported by the kubernetes provider", errs)
+	}
+}
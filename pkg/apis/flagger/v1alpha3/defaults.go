@@ -0,0 +1,53 @@
+package v1alpha3
+
+import (
+	"time"
+)
+
+// SetDefaults writes the default values into the canary spec, it's used by the scheduler
+// and by the defaulting admission webhook so that the stored spec matches what runs,
+// the provider is the mesh provider used when the canary doesn't specify one
+func (c *Canary) SetDefaults(provider string) {
+	if c.Spec.Provider != "" {
+		provider = c.Spec.Provider
+	}
+
+	if c.Spec.ProgressDeadlineSeconds == nil {
+		deadline := int32(ProgressDeadlineSeconds)
+		c.Spec.ProgressDeadlineSeconds = &deadline
+	}
+
+	analysis := &c.Spec.CanaryAnalysis
+	if interval, err := time.ParseDuration(analysis.Interval); analysis.Interval == "" || (err == nil && interval < MinAnalysisInterval) {
+		analysis.Interval = c.GetAnalysisInterval().String()
+	}
+
+	// Blue/Green is the only strategy supported by the kubernetes provider
+	if provider == "kubernetes" {
+		analysis.Match = nil
+		if analysis.Iterations < 1 {
+			analysis.Iterations = KubernetesIterations
+		}
+	}
+
+	if analysis.StepWeight > 0 && analysis.MaxWeight == 0 {
+		analysis.MaxWeight = MaxWeight
+	}
+
+	if analysis.MaxDuration != "" && analysis.MaxDurationAction == "" {
+		analysis.MaxDurationAction = MaxDurationRollback
+	}
+
+	// the interval of the metric template checks defaults to the template one
+	for i := range analysis.Metrics {
+		if analysis.Metrics[i].Interval == "" && analysis.Metrics[i].TemplateRef == nil {
+			analysis.Metrics[i].Interval = c.GetMetricInterval()
+		}
+	}
+
+	for i := range analysis.Webhooks {
+		if len(analysis.Webhooks[i].Timeout) < 2 {
+			analysis.Webhooks[i].Timeout = WebhookTimeout
+		}
+	}
+}
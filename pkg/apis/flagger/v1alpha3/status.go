@@ -11,6 +11,41 @@ type CanaryConditionType string
 const (
 	// PromotedType refers to the result of the last canary analysis
 	PromotedType CanaryConditionType = "Promoted"
+	// ReadyType is true when the primary runs a promoted revision and no analysis is underway
+	ReadyType CanaryConditionType = "Ready"
+	// ProgressingType is true while the canary analysis or the promotion is underway
+	ProgressingType CanaryConditionType = "Progressing"
+	// PausedType is true when the canary advancement is waiting for approval
+	PausedType CanaryConditionType = "Paused"
+	// RolledBackType is true when the last canary analysis failed and the canary was rolled back
+	RolledBackType CanaryConditionType = "RolledBack"
+	// SuspendedType is true when the canary advancement is frozen by spec.suspend
+	SuspendedType CanaryConditionType = "Suspended"
+)
+
+const (
+	// AwaitingApprovalReason means a confirm-rollout webhook halted the rollout
+	AwaitingApprovalReason = "AwaitingApproval"
+	// AwaitingPromotionApprovalReason means a confirm-promotion webhook halted the promotion
+	AwaitingPromotionApprovalReason = "AwaitingPromotionApproval"
+	// MetricCheckFailedReason means the failed checks threshold was reached due to metric checks
+	MetricCheckFailedReason = "MetricCheckFailed"
+	// WebhookRejectedReason means the failed checks threshold was reached due to webhook checks
+	WebhookRejectedReason = "WebhookRejected"
+	// ProgressDeadlineExceededReason means the canary workload didn't become ready in time
+	ProgressDeadlineExceededReason = "ProgressDeadlineExceeded"
+	// ManualRollbackReason means the user aborted the analysis in progress
+	ManualRollbackReason = "ManualRollback"
+	// SuspendedReason means the canary state machine is frozen by spec.suspend
+	SuspendedReason = "Suspended"
+	// ResumedReason means the canary state machine was unfrozen
+	ResumedReason = "Resumed"
+	// OutsideDeploymentWindowReason means the advancement is held until a deployment window opens
+	OutsideDeploymentWindowReason = "OutsideDeploymentWindow"
+	// BlackoutPeriodReason means the advancement is held until the blackout period ends
+	BlackoutPeriodReason = "BlackoutPeriod"
+	// MaxDurationExceededReason means the analysis ran for longer than the max duration
+	MaxDurationExceededReason = "MaxDurationExceeded"
 )
 
 // CanaryCondition is a status condition for a Canary
@@ -59,6 +94,57 @@ const (
 	CanaryPhaseFailed CanaryPhase = "Failed"
 )
 
+// CanaryRevisionOutcome is the result of the analysis of a canary revision
+type CanaryRevisionOutcome string
+
+const (
+	// CanaryRevisionSucceeded means the canary revision has been promoted
+	CanaryRevisionSucceeded CanaryRevisionOutcome = "Succeeded"
+	// CanaryRevisionFailed means the canary revision has been rolled back
+	CanaryRevisionFailed CanaryRevisionOutcome = "Failed"
+	// CanaryRevisionSuperseded means a new revision was detected during the analysis
+	CanaryRevisionSuperseded CanaryRevisionOutcome = "Superseded"
+)
+
+// CanaryRevision is the analysis record of a canary revision
+type CanaryRevision struct {
+	// Revision is the canary spec hash (the last applied spec)
+	Revision string `json:"revision"`
+
+	// StartTime of the canary analysis
+	StartTime metav1.Time `json:"startTime,omitempty"`
+
+	// EndTime of the canary analysis, empty while the analysis is underway
+	// +optional
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+
+	// Outcome of the canary analysis, empty while the analysis is underway
+	// +optional
+	Outcome CanaryRevisionOutcome `json:"outcome,omitempty"`
+
+	// FailedChecks is the failed check count of the canary analysis
+	FailedChecks int `json:"failedChecks"`
+
+	// CanaryWeight is the last traffic weight percentage routed to canary
+	CanaryWeight int `json:"canaryWeight"`
+
+	// FailedMetric is the name of the last metric check that halted the advancement
+	// +optional
+	FailedMetric string `json:"failedMetric,omitempty"`
+
+	// FailedWebhook is the name of the last webhook that halted the advancement
+	// +optional
+	FailedWebhook string `json:"failedWebhook,omitempty"`
+
+	// ResumeTime is the last time the canary analysis resumed after a suspension
+	// +optional
+	ResumeTime *metav1.Time `json:"resumeTime,omitempty"`
+
+	// PausedDuration is the time the canary analysis spent suspended or held outside the deployment windows
+	// +optional
+	PausedDuration *metav1.Duration `json:"pausedDuration,omitempty"`
+}
+
 // CanaryStatus is used for state persistence (read-only)
 type CanaryStatus struct {
 	Phase        CanaryPhase `json:"phase"`
@@ -66,6 +152,10 @@ type CanaryStatus struct {
 	CanaryWeight int         `json:"canaryWeight"`
 	Iterations   int         `json:"iterations"`
 	// +optional
+	StepChecks int `json:"stepChecks,omitempty"`
+	// +optional
+	LastStepTime metav1.Time `json:"lastStepTime,omitempty"`
+	// +optional
 	TrackedConfigs *map[string]string `json:"trackedConfigs,omitempty"`
 	// +optional
 	LastAppliedSpec string `json:"lastAppliedSpec,omitempty"`
@@ -73,6 +163,59 @@ type CanaryStatus struct {
 	LastPromotedSpec string `json:"lastPromotedSpec,omitempty"`
 	// +optional
 	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// RolloutStartTime is when Flagger last started to roll out the canary or the primary workload
+	// +optional
+	RolloutStartTime metav1.Time `json:"rolloutStartTime,omitempty"`
 	// +optional
 	Conditions []CanaryCondition `json:"conditions,omitempty"`
+	// +optional
+	History []CanaryRevision `json:"history,omitempty"`
+	// +optional
+	Judgement *CanaryJudgement `json:"judgement,omitempty"`
+}
+
+// CanaryVerdict is the outcome of the comparison of a canary metric with the primary one
+type CanaryVerdict string
+
+const (
+	// CanaryVerdictPass means the canary metric is not significantly worse than the primary one
+	CanaryVerdictPass CanaryVerdict = "Pass"
+	// CanaryVerdictFail means the canary metric is significantly worse than the primary one
+	CanaryVerdictFail CanaryVerdict = "Fail"
+	// CanaryVerdictNoData means the canary or the primary series are empty
+	CanaryVerdictNoData CanaryVerdict = "NoData"
+)
+
+// CanaryJudgement is the outcome of the last statistical judge run
+type CanaryJudgement struct {
+	// Score is the percentage of the metrics with data that passed
+	Score float64 `json:"score"`
+
+	// Time of the judge run
+	Time metav1.Time `json:"time"`
+
+	// Metrics holds the verdict of each metric
+	// +optional
+	Metrics []CanaryMetricJudgement `json:"metrics,omitempty"`
+}
+
+// CanaryMetricJudgement is the verdict of a canary metric
+type CanaryMetricJudgement struct {
+	// Name of the metric
+	Name string `json:"name"`
+
+	// Verdict of the comparison
+	Verdict CanaryVerdict `json:"verdict"`
+
+	// PValue of the Mann-Whitney U test
+	// +optional
+	PValue float64 `json:"pValue,omitempty"`
+
+	// PrimaryMedian is the median of the primary series
+	// +optional
+	PrimaryMedian float64 `json:"primaryMedian,omitempty"`
+
+	// CanaryMedian is the median of the canary series
+	// +optional
+	CanaryMedian float64 `json:"canaryMedian,omitempty"`
 }
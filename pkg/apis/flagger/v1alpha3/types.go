@@ -20,6 +20,7 @@ import (
 	"time"
 
 	hpav1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
@@ -30,7 +31,13 @@ const (
 	CanaryKind              = "Canary"
 	ProgressDeadlineSeconds = 600
 	AnalysisInterval        = 60 * time.Second
+	MinAnalysisInterval     = 10 * time.Second
 	MetricInterval          = "1m"
+	MaxWeight               = 100
+	WebhookTimeout          = "10s"
+	KubernetesIterations    = 10
+	// AbortAnnotation set to true on a canary rolls back the analysis in progress
+	AbortAnnotation = "flagger.app/abort"
 )
 
 // +genclient
@@ -55,6 +62,11 @@ type CanarySpec struct {
 	// +optional
 	MetricsServer string `json:"metricsServer,omitempty"`
 
+	// if specified replaces Prometheus with another metrics provider for this particular canary,
+	// the provider API address can be set with metricsServer
+	// +optional
+	MetricsProvider *CanaryMetricsProvider `json:"metricsProvider,omitempty"`
+
 	// reference to target resource
 	TargetRef hpav1.CrossVersionObjectReference `json:"targetRef"`
 
@@ -79,6 +91,10 @@ type CanarySpec struct {
 	// promote the canary without analysing it
 	// +optional
 	SkipAnalysis bool `json:"skipAnalysis,omitempty"`
+
+	// freeze the canary routing, scaling and analysis at the current step
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -91,6 +107,16 @@ type CanaryList struct {
 	Items []Canary `json:"items"`
 }
 
+// CanaryMetricsProvider is the metrics provider that runs the canary queries
+type CanaryMetricsProvider struct {
+	// type of the provider, can be prometheus or datadog
+	Type string `json:"type"`
+
+	// secret in the canary namespace that holds the provider credentials
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+}
+
 // CanaryService is used to create ClusterIP services
 // and Istio Virtual Service
 type CanaryService struct {
@@ -124,6 +150,51 @@ type CanaryAnalysis struct {
 	Webhooks   []CanaryWebhook                  `json:"webhooks,omitempty"`
 	Match      []istiov1alpha3.HTTPMatchRequest `json:"match,omitempty"`
 	Iterations int                              `json:"iterations,omitempty"`
+	// +optional
+	StepWeights []int `json:"stepWeights,omitempty"`
+	// +optional
+	StepInterval string `json:"stepInterval,omitempty"`
+	// +optional
+	StepChecks int `json:"stepChecks,omitempty"`
+	// +optional
+	MaxDuration string `json:"maxDuration,omitempty"`
+	// +optional
+	MaxDurationAction MaxDurationAction `json:"maxDurationAction,omitempty"`
+	// +optional
+	Alerts []CanaryAlert `json:"alerts,omitempty"`
+	// +optional
+	Schedule *CanarySchedule `json:"schedule,omitempty"`
+	// +optional
+	Judge *CanaryJudge `json:"judge,omitempty"`
+}
+
+// CanarySchedule restricts the canary advancement and promotion
+// to the allowed windows outside of the blackout periods
+type CanarySchedule struct {
+	// IANA time zone of the windows, defaults to UTC
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+	// the advancement is allowed when any of the windows is open
+	// +optional
+	Windows []CanaryWindow `json:"windows,omitempty"`
+	// the advancement is not allowed during the blackout periods
+	// +optional
+	Blackouts []CanaryBlackout `json:"blackouts,omitempty"`
+}
+
+// CanaryWindow is a recurring time window that starts
+// at the times matched by the cron expression and lasts for the duration
+type CanaryWindow struct {
+	Start    string `json:"start"`
+	Duration string `json:"duration"`
+}
+
+// CanaryBlackout is a time range when the advancement is not allowed
+type CanaryBlackout struct {
+	// +optional
+	Name  string      `json:"name,omitempty"`
+	Start metav1.Time `json:"start"`
+	End   metav1.Time `json:"end"`
 }
 
 // CanaryMetric holds the reference to Istio metrics used for canary analysis
@@ -132,7 +203,137 @@ type CanaryMetric struct {
 	Interval  string  `json:"interval,omitempty"`
 	Threshold float64 `json:"threshold"`
 	// +optional
+	ThresholdRange *CanaryThresholdRange `json:"thresholdRange,omitempty"`
+	// +optional
+	Comparison *CanaryMetricComparison `json:"comparison,omitempty"`
+	// +optional
 	Query string `json:"query,omitempty"`
+	// +optional
+	TemplateRef *MetricTemplateRef `json:"templateRef,omitempty"`
+	// latency percentile of the request-duration builtin metric, defaults to 99
+	// +optional
+	Percentile *float64 `json:"percentile,omitempty"`
+	// success rate objective in percentage of the error-budget-burn-rate builtin metric
+	// +optional
+	Objective *float64 `json:"objective,omitempty"`
+	// +optional
+	Aggregation *CanaryMetricAggregation `json:"aggregation,omitempty"`
+	// user-defined variables available in the query templates
+	// +optional
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// CanaryThresholdRange is the range of accepted values of a metric,
+// the bounds are inclusive and either of them can be omitted
+type CanaryThresholdRange struct {
+	// +optional
+	Min *float64 `json:"min,omitempty"`
+	// +optional
+	Max *float64 `json:"max,omitempty"`
+}
+
+// CanaryMetricComparison judges the canary metric relative to the same metric of the primary,
+// the check fails when the canary value is worse than the primary one by more than either limit
+type CanaryMetricComparison struct {
+	// max difference in percentage of the primary value
+	// +optional
+	MaxDeviationPercent *float64 `json:"maxDeviationPercent,omitempty"`
+	// max absolute difference
+	// +optional
+	MaxDelta *float64 `json:"maxDelta,omitempty"`
+	// true when higher values are better, defaults to true for the builtin request-success-rate metric only
+	// +optional
+	HigherIsBetter *bool `json:"higherIsBetter,omitempty"`
+}
+
+// CanaryMetricAggregation reduces the result of a custom or template metric query
+// to the values checked against the threshold
+type CanaryMetricAggregation struct {
+	// time range of the range query, the metric runs as an instant query when omitted
+	// +optional
+	Window string `json:"window,omitempty"`
+	// resolution of the range query, defaults to a tenth of the window
+	// +optional
+	Step string `json:"step,omitempty"`
+	// function applied to the samples of every series: min, max, avg, last or percentile, defaults to avg
+	// +optional
+	Function string `json:"function,omitempty"`
+	// percentile between 0 and 100 computed by the percentile function
+	// +optional
+	Percentile *float64 `json:"percentile,omitempty"`
+	// handling of the results with more than one series, defaults to any
+	// +optional
+	Series CanaryMetricSeries `json:"series,omitempty"`
+}
+
+// CanaryMetricSeries is the handling of the metric results with more than one series
+type CanaryMetricSeries string
+
+const (
+	// CanaryMetricSeriesAny checks every series, the check fails if any of them is out of range
+	CanaryMetricSeriesAny CanaryMetricSeries = "any"
+	// CanaryMetricSeriesAggregate reduces the series to a single value with the aggregation function
+	CanaryMetricSeriesAggregate CanaryMetricSeries = "aggregate"
+)
+
+// MetricTemplateRef holds the reference to a MetricTemplate,
+// the namespace defaults to the canary namespace
+type MetricTemplateRef struct {
+	Name string `json:"name"`
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// CanaryJudge compares the canary metrics with the primary ones over the analysis window
+// and passes the analysis step if the percentage of passed metrics reaches the threshold
+type CanaryJudge struct {
+	// min score (0-100) of the analysis step
+	Threshold float64 `json:"threshold"`
+	// significance level of the Mann-Whitney U test, defaults to 0.05
+	// +optional
+	Alpha float64 `json:"alpha,omitempty"`
+	// time range of the series, defaults to the time since the analysis started
+	// +optional
+	Window string `json:"window,omitempty"`
+	// resolution of the series, defaults to the metric interval
+	// +optional
+	Step string `json:"step,omitempty"`
+}
+
+// MaxDurationAction is the action taken when the analysis runs for longer than the max duration
+type MaxDurationAction string
+
+const (
+	// MaxDurationRollback routes all traffic back to primary and scales down the canary
+	MaxDurationRollback MaxDurationAction = "rollback"
+	// MaxDurationPromote promotes the canary without completing the analysis
+	MaxDurationPromote MaxDurationAction = "promote"
+)
+
+// AlertSeverity filters the notifications sent to an alert provider
+type AlertSeverity string
+
+const (
+	// AlertSeverityInfo sends all the canary notifications
+	AlertSeverityInfo AlertSeverity = "info"
+	// AlertSeverityError sends only the failed analysis and rollback notifications
+	AlertSeverityError AlertSeverity = "error"
+)
+
+// CanaryAlert routes the canary notifications to an AlertProvider
+type CanaryAlert struct {
+	Name string `json:"name"`
+	// +optional
+	Severity    AlertSeverity    `json:"severity,omitempty"`
+	ProviderRef AlertProviderRef `json:"providerRef"`
+}
+
+// AlertProviderRef holds the reference to an AlertProvider,
+// the namespace defaults to the canary namespace
+type AlertProviderRef struct {
+	Name string `json:"name"`
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // HookType can be pre, post or during rollout
@@ -189,8 +390,8 @@ func (c *Canary) GetAnalysisInterval() time.Duration {
 		return AnalysisInterval
 	}
 
-	if interval < 10*time.Second {
-		return time.Second * 10
+	if interval < MinAnalysisInterval {
+		return MinAnalysisInterval
 	}
 
 	return interval
@@ -200,3 +401,58 @@ func (c *Canary) GetAnalysisInterval() time.Duration {
 func (c *Canary) GetMetricInterval() string {
 	return MetricInterval
 }
+
+// IsAborted returns true if the user requested the rollback of the analysis in progress
+func (c *Canary) IsAborted() bool {
+	return c.Annotations[AbortAnnotation] == "true"
+}
+
+// GetMaxWeight returns the last step weight if step weights are specified, otherwise the max weight
+func (c *Canary) GetMaxWeight() int {
+	if n := len(c.Spec.CanaryAnalysis.StepWeights); n > 0 {
+		return c.Spec.CanaryAnalysis.StepWeights[n-1]
+	}
+	return c.Spec.CanaryAnalysis.MaxWeight
+}
+
+// GetNextStepWeight returns the canary weight of the step that follows the current weight,
+// with step weights it's the first step greater than the current weight
+func (c *Canary) GetNextStepWeight(canaryWeight int) int {
+	if len(c.Spec.CanaryAnalysis.StepWeights) > 0 {
+		for _, weight := range c.Spec.CanaryAnalysis.StepWeights {
+			if weight > canaryWeight {
+				return weight
+			}
+		}
+		return c.GetMaxWeight()
+	}
+
+	weight := canaryWeight + c.Spec.CanaryAnalysis.StepWeight
+	if weight > 100 {
+		weight = 100
+	}
+	return weight
+}
+
+// GetHigherIsBetter returns true if a canary value higher than the primary one is an improvement,
+// without higherIsBetter only the builtin success rate is better when higher
+func (m *CanaryMetric) GetHigherIsBetter() bool {
+	if m.Comparison != nil && m.Comparison.HigherIsBetter != nil {
+		return *m.Comparison.HigherIsBetter
+	}
+	return m.Name == "request-success-rate" && m.Query == "" && m.TemplateRef == nil
+}
+
+// GetThresholdRange returns the range of accepted values of the metric, without a threshold range
+// the threshold is the minimum of the builtin success rate and request rate and the maximum of the other metrics
+func (m *CanaryMetric) GetThresholdRange() CanaryThresholdRange {
+	if m.ThresholdRange != nil {
+		return *m.ThresholdRange
+	}
+
+	threshold := m.Threshold
+	if (m.Name == "request-success-rate" || m.Name == "request-rate") && m.Query == "" && m.TemplateRef == nil {
+		return CanaryThresholdRange{Min: &threshold}
+	}
+	return CanaryThresholdRange{Max: &threshold}
+}
@@ -22,10 +22,28 @@ package v1alpha3
 
 import (
 	istiov1alpha3 "github.com/weaveworks/flagger/pkg/apis/istio/v1alpha3"
-	v1 "k8s.io/api/autoscaling/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertProviderRef) DeepCopyInto(out *AlertProviderRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertProviderRef.
+func (in *AlertProviderRef) DeepCopy() *AlertProviderRef {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertProviderRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Canary) DeepCopyInto(out *Canary) {
 	*out = *in
@@ -54,13 +72,32 @@ func (in *Canary) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryAlert) DeepCopyInto(out *CanaryAlert) {
+	*out = *in
+	out.ProviderRef = in.ProviderRef
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryAlert.
+func (in *CanaryAlert) DeepCopy() *CanaryAlert {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryAlert)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CanaryAnalysis) DeepCopyInto(out *CanaryAnalysis) {
 	*out = *in
 	if in.Metrics != nil {
 		in, out := &in.Metrics, &out.Metrics
 		*out = make([]CanaryMetric, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.Webhooks != nil {
 		in, out := &in.Webhooks, &out.Webhooks
@@ -76,6 +113,26 @@ func (in *CanaryAnalysis) DeepCopyInto(out *CanaryAnalysis) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.StepWeights != nil {
+		in, out := &in.StepWeights, &out.StepWeights
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.Alerts != nil {
+		in, out := &in.Alerts, &out.Alerts
+		*out = make([]CanaryAlert, len(*in))
+		copy(*out, *in)
+	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(CanarySchedule)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Judge != nil {
+		in, out := &in.Judge, &out.Judge
+		*out = new(CanaryJudge)
+		**out = **in
+	}
 	return
 }
 
@@ -89,6 +146,24 @@ func (in *CanaryAnalysis) DeepCopy() *CanaryAnalysis {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryBlackout) DeepCopyInto(out *CanaryBlackout) {
+	*out = *in
+	in.Start.DeepCopyInto(&out.Start)
+	in.End.DeepCopyInto(&out.End)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryBlackout.
+func (in *CanaryBlackout) DeepCopy() *CanaryBlackout {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryBlackout)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CanaryCondition) DeepCopyInto(out *CanaryCondition) {
 	*out = *in
@@ -107,6 +182,44 @@ func (in *CanaryCondition) DeepCopy() *CanaryCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryJudge) DeepCopyInto(out *CanaryJudge) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryJudge.
+func (in *CanaryJudge) DeepCopy() *CanaryJudge {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryJudge)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryJudgement) DeepCopyInto(out *CanaryJudgement) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]CanaryMetricJudgement, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryJudgement.
+func (in *CanaryJudgement) DeepCopy() *CanaryJudgement {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryJudgement)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CanaryList) DeepCopyInto(out *CanaryList) {
 	*out = *in
@@ -143,6 +256,43 @@ func (in *CanaryList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CanaryMetric) DeepCopyInto(out *CanaryMetric) {
 	*out = *in
+	if in.ThresholdRange != nil {
+		in, out := &in.ThresholdRange, &out.ThresholdRange
+		*out = new(CanaryThresholdRange)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Comparison != nil {
+		in, out := &in.Comparison, &out.Comparison
+		*out = new(CanaryMetricComparison)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TemplateRef != nil {
+		in, out := &in.TemplateRef, &out.TemplateRef
+		*out = new(MetricTemplateRef)
+		**out = **in
+	}
+	if in.Percentile != nil {
+		in, out := &in.Percentile, &out.Percentile
+		*out = new(float64)
+		**out = **in
+	}
+	if in.Objective != nil {
+		in, out := &in.Objective, &out.Objective
+		*out = new(float64)
+		**out = **in
+	}
+	if in.Aggregation != nil {
+		in, out := &in.Aggregation, &out.Aggregation
+		*out = new(CanaryMetricAggregation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -156,6 +306,153 @@ func (in *CanaryMetric) DeepCopy() *CanaryMetric {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryMetricAggregation) DeepCopyInto(out *CanaryMetricAggregation) {
+	*out = *in
+	if in.Percentile != nil {
+		in, out := &in.Percentile, &out.Percentile
+		*out = new(float64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryMetricAggregation.
+func (in *CanaryMetricAggregation) DeepCopy() *CanaryMetricAggregation {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryMetricAggregation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryMetricComparison) DeepCopyInto(out *CanaryMetricComparison) {
+	*out = *in
+	if in.MaxDeviationPercent != nil {
+		in, out := &in.MaxDeviationPercent, &out.MaxDeviationPercent
+		*out = new(float64)
+		**out = **in
+	}
+	if in.MaxDelta != nil {
+		in, out := &in.MaxDelta, &out.MaxDelta
+		*out = new(float64)
+		**out = **in
+	}
+	if in.HigherIsBetter != nil {
+		in, out := &in.HigherIsBetter, &out.HigherIsBetter
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryMetricComparison.
+func (in *CanaryMetricComparison) DeepCopy() *CanaryMetricComparison {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryMetricComparison)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryMetricJudgement) DeepCopyInto(out *CanaryMetricJudgement) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryMetricJudgement.
+func (in *CanaryMetricJudgement) DeepCopy() *CanaryMetricJudgement {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryMetricJudgement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryMetricsProvider) DeepCopyInto(out *CanaryMetricsProvider) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryMetricsProvider.
+func (in *CanaryMetricsProvider) DeepCopy() *CanaryMetricsProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryMetricsProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryRevision) DeepCopyInto(out *CanaryRevision) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.EndTime != nil {
+		in, out := &in.EndTime, &out.EndTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ResumeTime != nil {
+		in, out := &in.ResumeTime, &out.ResumeTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PausedDuration != nil {
+		in, out := &in.PausedDuration, &out.PausedDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryRevision.
+func (in *CanaryRevision) DeepCopy() *CanaryRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryRevision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanarySchedule) DeepCopyInto(out *CanarySchedule) {
+	*out = *in
+	if in.Windows != nil {
+		in, out := &in.Windows, &out.Windows
+		*out = make([]CanaryWindow, len(*in))
+		copy(*out, *in)
+	}
+	if in.Blackouts != nil {
+		in, out := &in.Blackouts, &out.Blackouts
+		*out = make([]CanaryBlackout, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanarySchedule.
+func (in *CanarySchedule) DeepCopy() *CanarySchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(CanarySchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CanaryService) DeepCopyInto(out *CanaryService) {
 	*out = *in
@@ -223,15 +520,20 @@ func (in *CanaryService) DeepCopy() *CanaryService {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CanarySpec) DeepCopyInto(out *CanarySpec) {
 	*out = *in
+	if in.MetricsProvider != nil {
+		in, out := &in.MetricsProvider, &out.MetricsProvider
+		*out = new(CanaryMetricsProvider)
+		(*in).DeepCopyInto(*out)
+	}
 	out.TargetRef = in.TargetRef
 	if in.AutoscalerRef != nil {
 		in, out := &in.AutoscalerRef, &out.AutoscalerRef
-		*out = new(v1.CrossVersionObjectReference)
+		*out = new(autoscalingv1.CrossVersionObjectReference)
 		**out = **in
 	}
 	if in.IngressRef != nil {
 		in, out := &in.IngressRef, &out.IngressRef
-		*out = new(v1.CrossVersionObjectReference)
+		*out = new(autoscalingv1.CrossVersionObjectReference)
 		**out = **in
 	}
 	in.Service.DeepCopyInto(&out.Service)
@@ -257,6 +559,7 @@ func (in *CanarySpec) DeepCopy() *CanarySpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CanaryStatus) DeepCopyInto(out *CanaryStatus) {
 	*out = *in
+	in.LastStepTime.DeepCopyInto(&out.LastStepTime)
 	if in.TrackedConfigs != nil {
 		in, out := &in.TrackedConfigs, &out.TrackedConfigs
 		*out = new(map[string]string)
@@ -269,6 +572,7 @@ func (in *CanaryStatus) DeepCopyInto(out *CanaryStatus) {
 		}
 	}
 	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	in.RolloutStartTime.DeepCopyInto(&out.RolloutStartTime)
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]CanaryCondition, len(*in))
@@ -276,6 +580,18 @@ func (in *CanaryStatus) DeepCopyInto(out *CanaryStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]CanaryRevision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Judgement != nil {
+		in, out := &in.Judgement, &out.Judgement
+		*out = new(CanaryJudgement)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -289,6 +605,32 @@ func (in *CanaryStatus) DeepCopy() *CanaryStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryThresholdRange) DeepCopyInto(out *CanaryThresholdRange) {
+	*out = *in
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		*out = new(float64)
+		**out = **in
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		*out = new(float64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryThresholdRange.
+func (in *CanaryThresholdRange) DeepCopy() *CanaryThresholdRange {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryThresholdRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CanaryWebhook) DeepCopyInto(out *CanaryWebhook) {
 	*out = *in
@@ -338,3 +680,35 @@ func (in *CanaryWebhookPayload) DeepCopy() *CanaryWebhookPayload {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryWindow) DeepCopyInto(out *CanaryWindow) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryWindow.
+func (in *CanaryWindow) DeepCopy() *CanaryWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricTemplateRef) DeepCopyInto(out *MetricTemplateRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricTemplateRef.
+func (in *MetricTemplateRef) DeepCopy() *MetricTemplateRef {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricTemplateRef)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2019 The Flagger Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	AlertProviderKind = "AlertProvider"
+	// AlertProviderAddressKey is the Secret key that holds the hook address
+	AlertProviderAddressKey = "address"
+)
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AlertProvider is a specification for a canary notifications receiver
+type AlertProvider struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AlertProviderSpec `json:"spec"`
+}
+
+// AlertProviderSpec is the spec for an AlertProvider resource
+type AlertProviderSpec struct {
+	// type of the provider, can be slack or msteams
+	Type string `json:"type"`
+
+	// hook address of the provider
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// secret in the provider namespace that holds the hook address,
+	// takes precedence over the address field
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// Slack channel
+	// +optional
+	Channel string `json:"channel,omitempty"`
+
+	// Slack user name
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// namespaces of the canaries that can reference the provider from another namespace,
+	// the canaries from the provider namespace are always allowed
+	// +optional
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+}
+
+// AllowsNamespace returns true if the canaries from the namespace can reference the provider
+func (p *AlertProvider) AllowsNamespace(namespace string) bool {
+	if namespace == p.Namespace {
+		return true
+	}
+	for _, allowed := range p.Spec.AllowedNamespaces {
+		if allowed == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AlertProviderList is a list of AlertProvider resources
+type AlertProviderList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []AlertProvider `json:"items"`
+}
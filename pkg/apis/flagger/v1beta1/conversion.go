@@ -0,0 +1,123 @@
+package v1beta1
+
+import (
+	"github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+)
+
+// ConvertFrom converts a v1alpha3 canary to v1beta1,
+// the strategy is derived from the analysis fields the same way the scheduler does
+func (dst *Canary) ConvertFrom(src *v1alpha3.Canary) {
+	dst.TypeMeta = src.TypeMeta
+	dst.APIVersion = SchemeGroupVersion.String()
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	in := src.Spec.DeepCopy()
+	dst.Spec = CanarySpec{
+		Provider:                in.Provider,
+		MetricsServer:           in.MetricsServer,
+		TargetRef:               in.TargetRef,
+		AutoscalerRef:           in.AutoscalerRef,
+		IngressRef:              in.IngressRef,
+		ProgressDeadlineSeconds: in.ProgressDeadlineSeconds,
+		SkipAnalysis:            in.SkipAnalysis,
+		Suspend:                 in.Suspend,
+		MetricsProvider:         in.MetricsProvider,
+	}
+
+	dst.Spec.Service = CanaryService{
+		Port:          in.Service.Port,
+		PortName:      in.Service.PortName,
+		TargetPort:    in.Service.TargetPort,
+		PortDiscovery: in.Service.PortDiscovery,
+		Timeout:       in.Service.Timeout,
+		Hosts:         in.Service.Hosts,
+		Match:         in.Service.Match,
+		Retries:       in.Service.Retries,
+	}
+	if len(in.Service.Gateways) > 0 || in.Service.TrafficPolicy != nil || in.Service.Rewrite != nil ||
+		in.Service.Headers != nil || in.Service.CorsPolicy != nil {
+		dst.Spec.Service.Istio = &IstioService{
+			Gateways:      in.Service.Gateways,
+			TrafficPolicy: in.Service.TrafficPolicy,
+			Rewrite:       in.Service.Rewrite,
+			Headers:       in.Service.Headers,
+			CorsPolicy:    in.Service.CorsPolicy,
+		}
+	}
+	if in.Service.MeshName != "" || len(in.Service.Backends) > 0 {
+		dst.Spec.Service.AppMesh = &AppMeshService{
+			MeshName: in.Service.MeshName,
+			Backends: in.Service.Backends,
+		}
+	}
+
+	// the match conditions are used by the routers regardless of the iterations,
+	// the A/B testing strategy keeps them on the way back to v1alpha3
+	strategy := CanaryStrategyCanary
+	if len(in.CanaryAnalysis.Match) > 0 {
+		strategy = CanaryStrategyABTesting
+	} else if in.CanaryAnalysis.Iterations > 0 {
+		strategy = CanaryStrategyBlueGreen
+	}
+
+	dst.Spec.CanaryAnalysis = CanaryAnalysis{
+		Strategy:       strategy,
+		CanaryAnalysis: in.CanaryAnalysis,
+	}
+
+	dst.Status = *src.Status.DeepCopy()
+}
+
+// ConvertTo converts the canary to v1alpha3, the analysis fields
+// that don't apply to the strategy are dropped so that the scheduler picks the same strategy
+func (src *Canary) ConvertTo(dst *v1alpha3.Canary) {
+	dst.TypeMeta = src.TypeMeta
+	dst.APIVersion = v1alpha3.SchemeGroupVersion.String()
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	in := src.Spec.DeepCopy()
+	dst.Spec = v1alpha3.CanarySpec{
+		Provider:                in.Provider,
+		MetricsServer:           in.MetricsServer,
+		TargetRef:               in.TargetRef,
+		AutoscalerRef:           in.AutoscalerRef,
+		IngressRef:              in.IngressRef,
+		ProgressDeadlineSeconds: in.ProgressDeadlineSeconds,
+		SkipAnalysis:            in.SkipAnalysis,
+		Suspend:                 in.Suspend,
+		MetricsProvider:         in.MetricsProvider,
+	}
+
+	dst.Spec.Service = v1alpha3.CanaryService{
+		Port:          in.Service.Port,
+		PortName:      in.Service.PortName,
+		TargetPort:    in.Service.TargetPort,
+		PortDiscovery: in.Service.PortDiscovery,
+		Timeout:       in.Service.Timeout,
+		Hosts:         in.Service.Hosts,
+		Match:         in.Service.Match,
+		Retries:       in.Service.Retries,
+	}
+	if istio := in.Service.Istio; istio != nil {
+		dst.Spec.Service.Gateways = istio.Gateways
+		dst.Spec.Service.TrafficPolicy = istio.TrafficPolicy
+		dst.Spec.Service.Rewrite = istio.Rewrite
+		dst.Spec.Service.Headers = istio.Headers
+		dst.Spec.Service.CorsPolicy = istio.CorsPolicy
+	}
+	if appMesh := in.Service.AppMesh; appMesh != nil {
+		dst.Spec.Service.MeshName = appMesh.MeshName
+		dst.Spec.Service.Backends = appMesh.Backends
+	}
+
+	dst.Spec.CanaryAnalysis = in.CanaryAnalysis.CanaryAnalysis
+	switch in.CanaryAnalysis.Strategy {
+	case CanaryStrategyCanary:
+		dst.Spec.CanaryAnalysis.Iterations = 0
+		dst.Spec.CanaryAnalysis.Match = nil
+	case CanaryStrategyBlueGreen:
+		dst.Spec.CanaryAnalysis.Match = nil
+	}
+
+	dst.Status = *src.Status.DeepCopy()
+}
@@ -0,0 +1,194 @@
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	hpav1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+	istiov1alpha1 "github.com/weaveworks/flagger/pkg/apis/istio/common/v1alpha1"
+	istiov1alpha3 "github.com/weaveworks/flagger/pkg/apis/istio/v1alpha3"
+)
+
+func newTestCanaryV1alpha3() *v1alpha3.Canary {
+	minRPS, maxRPS, maxDeviation, percentile, objective := 50.0, 500.0, 10.0, 99.0, 99.9
+	resumeTime := metav1.NewTime(time.Date(2019, 12, 20, 9, 30, 0, 0, time.UTC))
+	return &v1alpha3.Canary{
+		TypeMeta: metav1.TypeMeta{APIVersion: v1alpha3.SchemeGroupVersion.String(), Kind: CanaryKind},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "podinfo",
+		},
+		Spec: v1alpha3.CanarySpec{
+			Provider: "istio",
+			MetricsProvider: &v1alpha3.CanaryMetricsProvider{
+				Type:      "datadog",
+				SecretRef: &corev1.LocalObjectReference{Name: "datadog"},
+			},
+			Suspend: true,
+			TargetRef: hpav1.CrossVersionObjectReference{
+				Name:       "podinfo",
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+			},
+			Service: v1alpha3.CanaryService{
+				Port:     9898,
+				Timeout:  "30s",
+				Gateways: []string{"public-gateway"},
+				Hosts:    []string{"app.example.com"},
+				Headers: &istiov1alpha3.Headers{
+					Request: &istiov1alpha3.HeaderOperations{Add: map[string]string{"x-envoy-upstream-rq-timeout-ms": "15000"}},
+				},
+				MeshName: "global",
+				Backends: []string{"backend.test"},
+			},
+			CanaryAnalysis: v1alpha3.CanaryAnalysis{
+				Interval:          "1m",
+				Threshold:         10,
+				MaxWeight:         50,
+				StepWeight:        10,
+				StepWeights:       []int{5, 10, 25},
+				StepInterval:      "5m",
+				StepChecks:        3,
+				MaxDuration:       "2h",
+				MaxDurationAction: v1alpha3.MaxDurationPromote,
+				Metrics: []v1alpha3.CanaryMetric{
+					{Name: "request-success-rate", Threshold: 99, Interval: "1m"},
+					{Name: "request-duration", Percentile: &percentile, Comparison: &v1alpha3.CanaryMetricComparison{MaxDeviationPercent: &maxDeviation}},
+					{Name: "error-budget-burn-rate", Threshold: 2, Objective: &objective},
+					{Name: "throughput", Query: "sum(rate(http_requests_total[1m]))", ThresholdRange: &v1alpha3.CanaryThresholdRange{Min: &minRPS, Max: &maxRPS}},
+					{Name: "error-rate", Threshold: 1, TemplateRef: &v1alpha3.MetricTemplateRef{Name: "error-rate", Namespace: "flagger"},
+						Variables: map[string]string{"route": "/api"}},
+					{Name: "pod-latency", Query: "max(pod_latency_seconds) by (pod)", Threshold: 1, Aggregation: &v1alpha3.CanaryMetricAggregation{
+						Window: "10m", Step: "30s", Function: "percentile", Percentile: &percentile, Series: v1alpha3.CanaryMetricSeriesAny}},
+				},
+				Webhooks: []v1alpha3.CanaryWebhook{
+					{Type: v1alpha3.PreRolloutHook, Name: "load-test", URL: "http://flagger-loadtester.test/", Timeout: "5s"},
+				},
+				Alerts: []v1alpha3.CanaryAlert{
+					{Name: "on-call", Severity: v1alpha3.AlertSeverityError, ProviderRef: v1alpha3.AlertProviderRef{Name: "on-call-slack", Namespace: "flagger"}},
+				},
+				Schedule: &v1alpha3.CanarySchedule{
+					TimeZone: "Europe/Berlin",
+					Windows:  []v1alpha3.CanaryWindow{{Start: "0 9 * * 1-5", Duration: "8h"}},
+					Blackouts: []v1alpha3.CanaryBlackout{
+						{Name: "holidays", Start: metav1.NewTime(time.Date(2019, 12, 24, 0, 0, 0, 0, time.UTC)), End: metav1.NewTime(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC))},
+					},
+				},
+				Judge: &v1alpha3.CanaryJudge{Threshold: 80, Alpha: 0.01, Window: "10m", Step: "30s"},
+			},
+		},
+		Status: v1alpha3.CanaryStatus{
+			Phase:           v1alpha3.CanaryPhaseSucceeded,
+			LastAppliedSpec: "123",
+			StepChecks:      2,
+			LastStepTime:    metav1.NewTime(time.Date(2019, 12, 20, 10, 0, 0, 0, time.UTC)),
+			Conditions: []v1alpha3.CanaryCondition{
+				{Type: v1alpha3.PromotedType, Status: "True", Reason: "Succeeded"},
+			},
+			History: []v1alpha3.CanaryRevision{
+				{
+					Revision:       "123",
+					Outcome:        v1alpha3.CanaryRevisionSucceeded,
+					CanaryWeight:   50,
+					ResumeTime:     &resumeTime,
+					PausedDuration: &metav1.Duration{Duration: 30 * time.Minute},
+				},
+			},
+			Judgement: &v1alpha3.CanaryJudgement{
+				Score: 50,
+				Time:  metav1.NewTime(time.Date(2019, 12, 20, 10, 0, 0, 0, time.UTC)),
+				Metrics: []v1alpha3.CanaryMetricJudgement{
+					{Name: "request-success-rate", Verdict: v1alpha3.CanaryVerdictPass, PValue: 0.4, PrimaryMedian: 99.5, CanaryMedian: 99.4},
+					{Name: "request-duration", Verdict: v1alpha3.CanaryVerdictFail, PValue: 0.001, PrimaryMedian: 120, CanaryMedian: 480},
+				},
+			},
+		},
+	}
+}
+
+func TestConversion_Strategy(t *testing.T) {
+	match := []istiov1alpha3.HTTPMatchRequest{
+		{Headers: map[string]istiov1alpha1.StringMatch{"cookie": {Regex: "^(.*?;)?(canary=always)(;.*)?$"}}},
+	}
+
+	tests := map[CanaryStrategy]func(cd *v1alpha3.Canary){
+		CanaryStrategyCanary: func(cd *v1alpha3.Canary) {},
+		CanaryStrategyBlueGreen: func(cd *v1alpha3.Canary) {
+			cd.Spec.CanaryAnalysis.Iterations = 10
+		},
+		CanaryStrategyABTesting: func(cd *v1alpha3.Canary) {
+			cd.Spec.CanaryAnalysis.Iterations = 10
+			cd.Spec.CanaryAnalysis.Match = match
+		},
+	}
+
+	for strategy, mutate := range tests {
+		src := newTestCanaryV1alpha3()
+		mutate(src)
+
+		beta := &Canary{}
+		beta.ConvertFrom(src)
+		if beta.Spec.CanaryAnalysis.Strategy != strategy {
+			t.Errorf("Got strategy %v wanted %v", beta.Spec.CanaryAnalysis.Strategy, strategy)
+		}
+		if beta.APIVersion != SchemeGroupVersion.String() {
+			t.Errorf("Got API version %v wanted %v", beta.APIVersion, SchemeGroupVersion.String())
+		}
+
+		dst := &v1alpha3.Canary{}
+		beta.ConvertTo(dst)
+		if diff := cmp.Diff(src, dst); diff != "" {
+			t.Errorf("%s round trip mismatch (-want +got):\n%s", strategy, diff)
+		}
+	}
+}
+
+func TestConversion_MatchWithoutIterations(t *testing.T) {
+	src := newTestCanaryV1alpha3()
+	src.Spec.CanaryAnalysis.Match = []istiov1alpha3.HTTPMatchRequest{
+		{Headers: map[string]istiov1alpha1.StringMatch{"x-canary": {Exact: "insider"}}},
+	}
+
+	beta := &Canary{}
+	beta.ConvertFrom(src)
+	if beta.Spec.CanaryAnalysis.Strategy != CanaryStrategyABTesting {
+		t.Errorf("Got strategy %v wanted %v", beta.Spec.CanaryAnalysis.Strategy, CanaryStrategyABTesting)
+	}
+
+	dst := &v1alpha3.Canary{}
+	beta.ConvertTo(dst)
+	if diff := cmp.Diff(src, dst); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestConversion_ProviderFields(t *testing.T) {
+	beta := &Canary{}
+	beta.ConvertFrom(newTestCanaryV1alpha3())
+
+	if beta.Spec.Service.Istio == nil || beta.Spec.Service.Istio.Gateways[0] != "public-gateway" {
+		t.Errorf("Got Istio service %v wanted the gateways", beta.Spec.Service.Istio)
+	}
+
+	if beta.Spec.Service.AppMesh == nil || beta.Spec.Service.AppMesh.MeshName != "global" {
+		t.Errorf("Got App Mesh service %v wanted the mesh name", beta.Spec.Service.AppMesh)
+	}
+
+	beta.Spec.Service.AppMesh = nil
+	beta.Spec.CanaryAnalysis.Strategy = CanaryStrategyCanary
+	beta.Spec.CanaryAnalysis.Iterations = 5
+
+	dst := &v1alpha3.Canary{}
+	beta.ConvertTo(dst)
+	if dst.Spec.Service.MeshName != "" {
+		t.Errorf("Got mesh name %v wanted empty", dst.Spec.Service.MeshName)
+	}
+	if dst.Spec.CanaryAnalysis.Iterations != 0 {
+		t.Errorf("Got iterations %v wanted %v for the canary strategy", dst.Spec.CanaryAnalysis.Iterations, 0)
+	}
+}
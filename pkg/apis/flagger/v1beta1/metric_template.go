@@ -0,0 +1,86 @@
+/*
+Copyright 2019 The Flagger Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	MetricTemplateKind = "MetricTemplate"
+)
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MetricTemplate is a specification for a reusable canary metric query
+type MetricTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec MetricTemplateSpec `json:"spec"`
+}
+
+// MetricTemplateSpec is the spec for a MetricTemplate resource
+type MetricTemplateSpec struct {
+	// metrics server used to run the query, defaults to the canary metrics server
+	// +optional
+	Provider MetricTemplateProvider `json:"provider,omitempty"`
+
+	// query template rendered with the canary variables
+	Query string `json:"query"`
+
+	// default interval used when the canary metric doesn't specify one
+	// +optional
+	Interval string `json:"interval,omitempty"`
+}
+
+// MetricTemplateProvider is the metrics server that runs the template query
+type MetricTemplateProvider struct {
+	// type of the metrics server, can be prometheus, datadog, influxdb or json
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// address of the metrics server
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// secret in the template namespace that holds the provider credentials,
+	// the entries prefixed with header_ are sent as request headers by the json provider
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// HTTP method of the json provider, can be GET or POST (default GET)
+	// +optional
+	Method string `json:"method,omitempty"`
+
+	// JSONPath expression that selects the metric value in the json provider response
+	// +optional
+	JSONPath string `json:"jsonPath,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MetricTemplateList is a list of MetricTemplate resources
+type MetricTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []MetricTemplate `json:"items"`
+}
@@ -0,0 +1,18 @@
+package v1beta1
+
+import (
+	"github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+)
+
+// the status is identical in v1alpha3 and v1beta1
+type (
+	CanaryStatus          = v1alpha3.CanaryStatus
+	CanaryPhase           = v1alpha3.CanaryPhase
+	CanaryConditionType   = v1alpha3.CanaryConditionType
+	CanaryCondition       = v1alpha3.CanaryCondition
+	CanaryRevisionOutcome = v1alpha3.CanaryRevisionOutcome
+	CanaryRevision        = v1alpha3.CanaryRevision
+	CanaryVerdict         = v1alpha3.CanaryVerdict
+	CanaryJudgement       = v1alpha3.CanaryJudgement
+	CanaryMetricJudgement = v1alpha3.CanaryMetricJudgement
+)
@@ -0,0 +1,170 @@
+/*
+Copyright 2019 The Flagger Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	hpav1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+	istiov1alpha3 "github.com/weaveworks/flagger/pkg/apis/istio/v1alpha3"
+)
+
+const (
+	CanaryKind = "Canary"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Canary is a specification for a Canary resource
+type Canary struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CanarySpec   `json:"spec"`
+	Status CanaryStatus `json:"status"`
+}
+
+// CanarySpec is the spec for a Canary resource
+type CanarySpec struct {
+	// if specified overwrites the -mesh-provider flag for this particular canary
+	// +optional
+	Provider string `json:"provider,omitempty"`
+
+	// if specified overwrites the -metrics-server flag for this particular canary
+	// +optional
+	MetricsServer string `json:"metricsServer,omitempty"`
+
+	// if specified replaces Prometheus with another metrics provider for this particular canary,
+	// the provider API address can be set with metricsServer
+	// +optional
+	MetricsProvider *CanaryMetricsProvider `json:"metricsProvider,omitempty"`
+
+	// reference to target resource
+	TargetRef hpav1.CrossVersionObjectReference `json:"targetRef"`
+
+	// reference to autoscaling resource
+	// +optional
+	AutoscalerRef *hpav1.CrossVersionObjectReference `json:"autoscalerRef,omitempty"`
+
+	// reference to NGINX ingress resource
+	// +optional
+	IngressRef *hpav1.CrossVersionObjectReference `json:"ingressRef,omitempty"`
+
+	// virtual service spec
+	Service CanaryService `json:"service"`
+
+	// metrics and thresholds
+	CanaryAnalysis CanaryAnalysis `json:"canaryAnalysis"`
+
+	// the maximum time in seconds for a canary deployment to make progress
+	// before it is considered to be failed. Defaults to ten minutes.
+	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+
+	// promote the canary without analysing it
+	// +optional
+	SkipAnalysis bool `json:"skipAnalysis,omitempty"`
+
+	// freeze the canary routing, scaling and analysis at the current step
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CanaryList is a list of Canary resources
+type CanaryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []Canary `json:"items"`
+}
+
+// CanaryService is used to create ClusterIP services
+// and the service mesh or ingress routing objects
+type CanaryService struct {
+	Port          int32              `json:"port"`
+	PortName      string             `json:"portName,omitempty"`
+	TargetPort    intstr.IntOrString `json:"targetPort,omitempty"`
+	PortDiscovery bool               `json:"portDiscovery"`
+	// HTTP routing, used by Istio and App Mesh
+	Timeout string                           `json:"timeout,omitempty"`
+	Hosts   []string                         `json:"hosts,omitempty"`
+	Match   []istiov1alpha3.HTTPMatchRequest `json:"match,omitempty"`
+	Retries *istiov1alpha3.HTTPRetry         `json:"retries,omitempty"`
+	// +optional
+	Istio *IstioService `json:"istio,omitempty"`
+	// +optional
+	AppMesh *AppMeshService `json:"appMesh,omitempty"`
+}
+
+// IstioService holds the Istio specific routing settings
+type IstioService struct {
+	Gateways      []string                     `json:"gateways,omitempty"`
+	TrafficPolicy *istiov1alpha3.TrafficPolicy `json:"trafficPolicy,omitempty"`
+	Rewrite       *istiov1alpha3.HTTPRewrite   `json:"rewrite,omitempty"`
+	Headers       *istiov1alpha3.Headers       `json:"headers,omitempty"`
+	CorsPolicy    *istiov1alpha3.CorsPolicy    `json:"corsPolicy,omitempty"`
+}
+
+// AppMeshService holds the App Mesh specific routing settings
+type AppMeshService struct {
+	MeshName string   `json:"meshName,omitempty"`
+	Backends []string `json:"backends,omitempty"`
+}
+
+// CanaryStrategy is the way traffic is shifted to the canary during the analysis
+type CanaryStrategy string
+
+const (
+	// CanaryStrategyCanary increases the canary traffic weight with stepWeight until maxWeight is reached
+	CanaryStrategyCanary CanaryStrategy = "Canary"
+	// CanaryStrategyBlueGreen routes all traffic to canary after the iterations are done
+	CanaryStrategyBlueGreen CanaryStrategy = "BlueGreen"
+	// CanaryStrategyABTesting routes the requests matching the match conditions to canary
+	CanaryStrategyABTesting CanaryStrategy = "ABTesting"
+)
+
+// CanaryAnalysis is used to describe how the analysis should be done,
+// the analysis fields are shared with v1alpha3 and only the strategy is specific to v1beta1
+type CanaryAnalysis struct {
+	Strategy                CanaryStrategy `json:"strategy"`
+	v1alpha3.CanaryAnalysis `json:",inline"`
+}
+
+// the types below are identical in v1alpha3 and v1beta1
+type (
+	CanaryMetricsProvider   = v1alpha3.CanaryMetricsProvider
+	CanarySchedule          = v1alpha3.CanarySchedule
+	CanaryWindow            = v1alpha3.CanaryWindow
+	CanaryBlackout          = v1alpha3.CanaryBlackout
+	CanaryMetric            = v1alpha3.CanaryMetric
+	CanaryThresholdRange    = v1alpha3.CanaryThresholdRange
+	CanaryMetricComparison  = v1alpha3.CanaryMetricComparison
+	CanaryMetricAggregation = v1alpha3.CanaryMetricAggregation
+	CanaryMetricSeries      = v1alpha3.CanaryMetricSeries
+	MetricTemplateRef       = v1alpha3.MetricTemplateRef
+	CanaryJudge             = v1alpha3.CanaryJudge
+	MaxDurationAction       = v1alpha3.MaxDurationAction
+	AlertSeverity           = v1alpha3.AlertSeverity
+	CanaryAlert             = v1alpha3.CanaryAlert
+	AlertProviderRef        = v1alpha3.AlertProviderRef
+	HookType                = v1alpha3.HookType
+	CanaryWebhook           = v1alpha3.CanaryWebhook
+)
@@ -0,0 +1,285 @@
+package canary
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+)
+
+// daemonSetScaleDownLabel is a node selector that doesn't match any node,
+// DaemonSets can't be scaled to zero replicas so Flagger uses it to evict the canary pods
+const daemonSetScaleDownLabel = "flagger.app/scale-to-zero"
+
+// createPrimaryDaemonSet creates the primary daemonset, secrets and config maps
+// and returns the pod selector label and container ports
+func (c *Deployer) createPrimaryDaemonSet(cd *flaggerv1.Canary) (string, map[string]int32, error) {
+	targetName := cd.Spec.TargetRef.Name
+	primaryName := fmt.Sprintf("%s-primary", cd.Spec.TargetRef.Name)
+
+	canaryDae, err := c.KubeClient.AppsV1().DaemonSets(cd.Namespace).Get(targetName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil, fmt.Errorf("daemonset %s.%s not found, retrying", targetName, cd.Namespace)
+		}
+		return "", nil, err
+	}
+
+	label, err := c.getSelectorLabel(canaryDae.Spec.Selector)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid label selector! DaemonSet %s.%s spec.selector.matchLabels must contain selector 'app: %s'",
+			targetName, cd.Namespace, targetName)
+	}
+
+	var ports map[string]int32
+	if cd.Spec.Service.PortDiscovery {
+		p, err := c.getPorts(cd, canaryDae.Spec.Template.Spec.Containers)
+		if err != nil {
+			return "", nil, fmt.Errorf("port discovery failed with error: %v", err)
+		}
+		ports = p
+	}
+
+	_, err = c.KubeClient.AppsV1().DaemonSets(cd.Namespace).Get(primaryName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		// create primary secrets and config maps
+		configRefs, err := c.ConfigTracker.GetTargetConfigs(cd)
+		if err != nil {
+			return "", nil, err
+		}
+		if err := c.ConfigTracker.CreatePrimaryConfigs(cd, configRefs); err != nil {
+			return "", nil, err
+		}
+
+		template := makeDaemonSetTemplate(canaryDae.Spec.Template)
+		annotations, err := c.makeAnnotations(template.Annotations)
+		if err != nil {
+			return "", nil, err
+		}
+
+		// create primary daemonset
+		primaryDae := &appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      primaryName,
+				Namespace: cd.Namespace,
+				Labels: map[string]string{
+					label: primaryName,
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(cd, schema.GroupVersionKind{
+						Group:   flaggerv1.SchemeGroupVersion.Group,
+						Version: flaggerv1.SchemeGroupVersion.Version,
+						Kind:    flaggerv1.CanaryKind,
+					}),
+				},
+			},
+			Spec: appsv1.DaemonSetSpec{
+				MinReadySeconds:      canaryDae.Spec.MinReadySeconds,
+				RevisionHistoryLimit: canaryDae.Spec.RevisionHistoryLimit,
+				UpdateStrategy:       canaryDae.Spec.UpdateStrategy,
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						label: primaryName,
+					},
+				},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels:      makePrimaryLabels(template.Labels, primaryName, label),
+						Annotations: annotations,
+					},
+					// update spec with the primary secrets and config maps
+					Spec: c.ConfigTracker.ApplyPrimaryConfigs(template.Spec, configRefs),
+				},
+			},
+		}
+
+		_, err = c.KubeClient.AppsV1().DaemonSets(cd.Namespace).Create(primaryDae)
+		if err != nil {
+			return "", nil, err
+		}
+
+		c.Logger.With("canary", fmt.Sprintf("%s.%s", cd.Name, cd.Namespace)).Infof("DaemonSet %s.%s created", primaryDae.GetName(), cd.Namespace)
+	}
+
+	return label, ports, nil
+}
+
+// promoteDaemonSet copies the pod spec, secrets and config maps from the canary daemonset to primary
+func (c *Deployer) promoteDaemonSet(cd *flaggerv1.Canary) error {
+	targetName := cd.Spec.TargetRef.Name
+	primaryName := fmt.Sprintf("%s-primary", targetName)
+
+	canary, err := c.KubeClient.AppsV1().DaemonSets(cd.Namespace).Get(targetName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("daemonset %s.%s not found", targetName, cd.Namespace)
+		}
+		return fmt.Errorf("daemonset %s.%s query error %v", targetName, cd.Namespace, err)
+	}
+
+	label, err := c.getSelectorLabel(canary.Spec.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid label selector! DaemonSet %s.%s spec.selector.matchLabels must contain selector 'app: %s'",
+			targetName, cd.Namespace, targetName)
+	}
+
+	primary, err := c.KubeClient.AppsV1().DaemonSets(cd.Namespace).Get(primaryName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("daemonset %s.%s not found", primaryName, cd.Namespace)
+		}
+		return fmt.Errorf("daemonset %s.%s query error %v", primaryName, cd.Namespace, err)
+	}
+
+	// promote secrets and config maps
+	configRefs, err := c.ConfigTracker.GetTargetConfigs(cd)
+	if err != nil {
+		return err
+	}
+	if err := c.ConfigTracker.CreatePrimaryConfigs(cd, configRefs); err != nil {
+		return err
+	}
+
+	template := makeDaemonSetTemplate(canary.Spec.Template)
+
+	primaryCopy := primary.DeepCopy()
+	primaryCopy.Spec.MinReadySeconds = canary.Spec.MinReadySeconds
+	primaryCopy.Spec.RevisionHistoryLimit = canary.Spec.RevisionHistoryLimit
+	primaryCopy.Spec.UpdateStrategy = canary.Spec.UpdateStrategy
+
+	// update spec with primary secrets and config maps
+	primaryCopy.Spec.Template.Spec = c.ConfigTracker.ApplyPrimaryConfigs(template.Spec, configRefs)
+
+	// update pod annotations to ensure a rolling update
+	annotations, err := c.makeAnnotations(template.Annotations)
+	if err != nil {
+		return err
+	}
+	primaryCopy.Spec.Template.Annotations = annotations
+
+	primaryCopy.Spec.Template.Labels = makePrimaryLabels(template.Labels, primaryName, label)
+
+	// apply update
+	_, err = c.KubeClient.AppsV1().DaemonSets(cd.Namespace).Update(primaryCopy)
+	if err != nil {
+		return fmt.Errorf("updating daemonset %s.%s template spec failed: %v",
+			primaryCopy.GetName(), primaryCopy.Namespace, err)
+	}
+
+	return nil
+}
+
+// scaleDaemonSet schedules or evicts the canary pods by removing or adding a non-matching node selector
+func (c *Deployer) scaleDaemonSet(cd *flaggerv1.Canary, enabled bool) error {
+	targetName := cd.Spec.TargetRef.Name
+	dae, err := c.KubeClient.AppsV1().DaemonSets(cd.Namespace).Get(targetName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("daemonset %s.%s not found", targetName, cd.Namespace)
+		}
+		return fmt.Errorf("daemonset %s.%s query error %v", targetName, cd.Namespace, err)
+	}
+
+	// skip update if the daemonset is already in the desired state
+	_, scaledDown := dae.Spec.Template.Spec.NodeSelector[daemonSetScaleDownLabel]
+	if scaledDown == !enabled {
+		return nil
+	}
+
+	daeCopy := dae.DeepCopy()
+	if enabled {
+		daeCopy.Spec.Template = *makeDaemonSetTemplate(dae.Spec.Template)
+	} else {
+		if daeCopy.Spec.Template.Spec.NodeSelector == nil {
+			daeCopy.Spec.Template.Spec.NodeSelector = make(map[string]string)
+		}
+		daeCopy.Spec.Template.Spec.NodeSelector[daemonSetScaleDownLabel] = "true"
+	}
+
+	_, err = c.KubeClient.AppsV1().DaemonSets(dae.Namespace).Update(daeCopy)
+	if err != nil {
+		return fmt.Errorf("scaling %s.%s failed: %v", daeCopy.GetName(), daeCopy.Namespace, err)
+	}
+	return nil
+}
+
+// isPrimaryDaemonSetReady checks the primary daemonset rollout status
+func (c *Deployer) isPrimaryDaemonSetReady(cd *flaggerv1.Canary) (bool, error) {
+	primaryName := fmt.Sprintf("%s-primary", cd.Spec.TargetRef.Name)
+	primary, err := c.KubeClient.AppsV1().DaemonSets(cd.Namespace).Get(primaryName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return true, fmt.Errorf("daemonset %s.%s not found", primaryName, cd.Namespace)
+		}
+		return true, fmt.Errorf("daemonset %s.%s query error %v", primaryName, cd.Namespace, err)
+	}
+
+	retriable, err := c.isDaemonSetReady(cd, primary)
+	if err != nil {
+		return retriable, fmt.Errorf("Halt advancement %s.%s %s", primaryName, cd.Namespace, err.Error())
+	}
+
+	return true, nil
+}
+
+// isCanaryDaemonSetReady checks the canary daemonset rollout status
+func (c *Deployer) isCanaryDaemonSetReady(cd *flaggerv1.Canary) (bool, error) {
+	targetName := cd.Spec.TargetRef.Name
+	canary, err := c.KubeClient.AppsV1().DaemonSets(cd.Namespace).Get(targetName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return true, fmt.Errorf("daemonset %s.%s not found", targetName, cd.Namespace)
+		}
+		return true, fmt.Errorf("daemonset %s.%s query error %v", targetName, cd.Namespace, err)
+	}
+
+	retriable, err := c.isDaemonSetReady(cd, canary)
+	if err != nil {
+		if retriable {
+			return retriable, fmt.Errorf("Halt advancement %s.%s %s", targetName, cd.Namespace, err.Error())
+		} else {
+			return retriable, fmt.Errorf("daemonset does not have minimum availability for more than %vs",
+				cd.GetProgressDeadlineSeconds())
+		}
+	}
+
+	return true, nil
+}
+
+// isDaemonSetReady determines if a daemonset is ready by checking the number of updated and available pods
+// if the rollout is stuck for more than the canary progress deadline it returns a non retriable error
+func (c *Deployer) isDaemonSetReady(cd *flaggerv1.Canary, daemonSet *appsv1.DaemonSet) (bool, error) {
+	if daemonSet.Generation > daemonSet.Status.ObservedGeneration {
+		return true, fmt.Errorf("waiting for rollout to finish: observed daemonset generation less then desired generation")
+	}
+
+	retriable := !hasProgressDeadlineExceeded(cd)
+
+	if daemonSet.Status.UpdatedNumberScheduled < daemonSet.Status.DesiredNumberScheduled {
+		return retriable, fmt.Errorf("waiting for rollout to finish: %d out of %d new pods have been updated",
+			daemonSet.Status.UpdatedNumberScheduled, daemonSet.Status.DesiredNumberScheduled)
+	} else if daemonSet.Status.NumberAvailable < daemonSet.Status.DesiredNumberScheduled {
+		return retriable, fmt.Errorf("waiting for rollout to finish: %d of %d updated pods are available",
+			daemonSet.Status.NumberAvailable, daemonSet.Status.DesiredNumberScheduled)
+	}
+
+	return true, nil
+}
+
+// makeDaemonSetTemplate returns a copy of the pod template without the scale down node selector
+func makeDaemonSetTemplate(template corev1.PodTemplateSpec) *corev1.PodTemplateSpec {
+	res := template.DeepCopy()
+	if _, ok := res.Spec.NodeSelector[daemonSetScaleDownLabel]; ok {
+		delete(res.Spec.NodeSelector, daemonSetScaleDownLabel)
+		if len(res.Spec.NodeSelector) == 0 {
+			res.Spec.NodeSelector = nil
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,165 @@
+package canary
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+)
+
+func TestDaemonSet_Sync(t *testing.T) {
+	mocks := SetupMocksWithCanary(newTestDaemonSetCanary())
+	_, _, err := mocks.deployer.Initialize(mocks.canary, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	daePrimary, err := mocks.kubeClient.AppsV1().DaemonSets("default").Get("podinfo-ds-primary", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	dae := newTestDaemonSet()
+	primaryImage := daePrimary.Spec.Template.Spec.Containers[0].Image
+	sourceImage := dae.Spec.Template.Spec.Containers[0].Image
+	if primaryImage != sourceImage {
+		t.Errorf("Got image %s wanted %s", primaryImage, sourceImage)
+	}
+
+	if _, ok := daePrimary.Spec.Template.Spec.NodeSelector[daemonSetScaleDownLabel]; ok {
+		t.Errorf("Primary daemonset has the scale down node selector")
+	}
+
+	configPrimary, err := mocks.kubeClient.CoreV1().ConfigMaps("default").Get("podinfo-config-all-env-primary", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if configPrimary.Data["color"] != NewTestConfigMapEnv().Data["color"] {
+		t.Errorf("Got ConfigMap color %s wanted %s", configPrimary.Data["color"], NewTestConfigMapEnv().Data["color"])
+	}
+
+	canary, err := mocks.kubeClient.AppsV1().DaemonSets("default").Get("podinfo-ds", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, ok := canary.Spec.Template.Spec.NodeSelector[daemonSetScaleDownLabel]; !ok {
+		t.Errorf("Canary daemonset is missing the scale down node selector")
+	}
+}
+
+func TestDaemonSet_ScaleDoesNotChangeSpec(t *testing.T) {
+	mocks := SetupMocksWithCanary(newTestDaemonSetCanary())
+	_, _, err := mocks.deployer.Initialize(mocks.canary, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = mocks.deployer.SyncStatus(mocks.canary, flaggerv1.CanaryStatus{Phase: flaggerv1.CanaryPhaseInitialized})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cd, err := mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = mocks.deployer.ScaleUp(cd)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	canary, err := mocks.kubeClient.AppsV1().DaemonSets("default").Get("podinfo-ds", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, ok := canary.Spec.Template.Spec.NodeSelector[daemonSetScaleDownLabel]; ok {
+		t.Errorf("Canary daemonset has the scale down node selector after scale up")
+	}
+
+	isNew, err := mocks.deployer.HasDeploymentChanged(cd)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if isNew {
+		t.Errorf("Got %v wanted %v", isNew, false)
+	}
+}
+
+func TestDaemonSet_Promote(t *testing.T) {
+	mocks := SetupMocksWithCanary(newTestDaemonSetCanary())
+	_, _, err := mocks.deployer.Initialize(mocks.canary, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	dae2 := newTestDaemonSet()
+	dae2.Spec.Template.Spec.Containers[0].Image = "quay.io/stefanprodan/podinfo:1.2.1"
+	_, err = mocks.kubeClient.AppsV1().DaemonSets("default").Update(dae2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	isNew, err := mocks.deployer.HasDeploymentChanged(mocks.canary)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if !isNew {
+		t.Errorf("Got %v wanted %v", isNew, true)
+	}
+
+	err = mocks.deployer.Promote(mocks.canary)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	daePrimary, err := mocks.kubeClient.AppsV1().DaemonSets("default").Get("podinfo-ds-primary", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	primaryImage := daePrimary.Spec.Template.Spec.Containers[0].Image
+	sourceImage := dae2.Spec.Template.Spec.Containers[0].Image
+	if primaryImage != sourceImage {
+		t.Errorf("Got image %s wanted %s", primaryImage, sourceImage)
+	}
+}
+
+func TestDaemonSet_IsReady(t *testing.T) {
+	mocks := SetupMocksWithCanary(newTestDaemonSetCanary())
+	_, _, err := mocks.deployer.Initialize(mocks.canary, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, err = mocks.deployer.IsPrimaryReady(mocks.canary)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	dae, err := mocks.kubeClient.AppsV1().DaemonSets("default").Get("podinfo-ds", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	dae.Status.DesiredNumberScheduled = 2
+	dae.Status.UpdatedNumberScheduled = 1
+	_, err = mocks.kubeClient.AppsV1().DaemonSets("default").UpdateStatus(dae)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	retriable, err := mocks.deployer.IsCanaryReady(mocks.canary)
+	if err == nil {
+		t.Errorf("Expected canary readiness check to fail")
+	}
+
+	if !retriable {
+		t.Errorf("Got retriable %v wanted %v", retriable, true)
+	}
+}
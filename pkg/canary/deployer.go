@@ -34,9 +34,22 @@ type Deployer struct {
 // scales to zero the canary deployment and returns the pod selector label and container ports
 func (c *Deployer) Initialize(cd *flaggerv1.Canary, skipLivenessChecks bool) (label string, ports map[string]int32, err error) {
 	primaryName := fmt.Sprintf("%s-primary", cd.Spec.TargetRef.Name)
-	label, ports, err = c.createPrimaryDeployment(cd)
-	if err != nil {
-		return "", ports, fmt.Errorf("creating deployment %s.%s failed: %v", primaryName, cd.Namespace, err)
+	switch cd.Spec.TargetRef.Kind {
+	case "DaemonSet":
+		label, ports, err = c.createPrimaryDaemonSet(cd)
+		if err != nil {
+			return "", ports, fmt.Errorf("creating daemonset %s.%s failed: %v", primaryName, cd.Namespace, err)
+		}
+	case "StatefulSet":
+		label, ports, err = c.createPrimaryStatefulSet(cd)
+		if err != nil {
+			return "", ports, fmt.Errorf("creating statefulset %s.%s failed: %v", primaryName, cd.Namespace, err)
+		}
+	default:
+		label, ports, err = c.createPrimaryDeployment(cd)
+		if err != nil {
+			return "", ports, fmt.Errorf("creating deployment %s.%s failed: %v", primaryName, cd.Namespace, err)
+		}
 	}
 
 	if cd.Status.Phase == "" || cd.Status.Phase == flaggerv1.CanaryPhaseInitializing {
@@ -63,6 +76,13 @@ func (c *Deployer) Initialize(cd *flaggerv1.Canary, skipLivenessChecks bool) (la
 
 // Promote copies the pod spec, secrets and config maps from canary to primary
 func (c *Deployer) Promote(cd *flaggerv1.Canary) error {
+	switch cd.Spec.TargetRef.Kind {
+	case "DaemonSet":
+		return c.promoteDaemonSet(cd)
+	case "StatefulSet":
+		return c.promoteStatefulSet(cd)
+	}
+
 	targetName := cd.Spec.TargetRef.Name
 	primaryName := fmt.Sprintf("%s-primary", targetName)
 
@@ -74,7 +94,7 @@ func (c *Deployer) Promote(cd *flaggerv1.Canary) error {
 		return fmt.Errorf("deployment %s.%s query error %v", targetName, cd.Namespace, err)
 	}
 
-	label, err := c.getSelectorLabel(canary)
+	label, err := c.getSelectorLabel(canary.Spec.Selector)
 	if err != nil {
 		return fmt.Errorf("invalid label selector! Deployment %s.%s spec.selector.matchLabels must contain selector 'app: %s'",
 			targetName, cd.Namespace, targetName)
@@ -132,22 +152,18 @@ func (c *Deployer) Promote(cd *flaggerv1.Canary) error {
 	return nil
 }
 
-// HasDeploymentChanged returns true if the canary deployment pod spec has changed
+// HasDeploymentChanged returns true if the canary workload pod spec has changed
 func (c *Deployer) HasDeploymentChanged(cd *flaggerv1.Canary) (bool, error) {
-	targetName := cd.Spec.TargetRef.Name
-	canary, err := c.KubeClient.AppsV1().Deployments(cd.Namespace).Get(targetName, metav1.GetOptions{})
+	template, err := getTargetTemplate(c.KubeClient, cd)
 	if err != nil {
-		if errors.IsNotFound(err) {
-			return false, fmt.Errorf("deployment %s.%s not found", targetName, cd.Namespace)
-		}
-		return false, fmt.Errorf("deployment %s.%s query error %v", targetName, cd.Namespace, err)
+		return false, err
 	}
 
 	if cd.Status.LastAppliedSpec == "" {
 		return true, nil
 	}
 
-	newHash, err := hashstructure.Hash(canary.Spec.Template, nil)
+	newHash, err := hashstructure.Hash(*template, nil)
 	if err != nil {
 		return false, fmt.Errorf("hash error %v", err)
 	}
@@ -166,6 +182,13 @@ func (c *Deployer) HasDeploymentChanged(cd *flaggerv1.Canary) (bool, error) {
 
 // Scale sets the canary deployment replicas
 func (c *Deployer) Scale(cd *flaggerv1.Canary, replicas int32) error {
+	switch cd.Spec.TargetRef.Kind {
+	case "DaemonSet":
+		return c.scaleDaemonSet(cd, replicas > 0)
+	case "StatefulSet":
+		return c.scaleStatefulSet(cd, int32p(replicas))
+	}
+
 	targetName := cd.Spec.TargetRef.Name
 	dep, err := c.KubeClient.AppsV1().Deployments(cd.Namespace).Get(targetName, metav1.GetOptions{})
 	if err != nil {
@@ -185,7 +208,15 @@ func (c *Deployer) Scale(cd *flaggerv1.Canary, replicas int32) error {
 	return nil
 }
 
+// ScaleUp restores the canary deployment replicas (defaults to one replica)
 func (c *Deployer) ScaleUp(cd *flaggerv1.Canary) error {
+	switch cd.Spec.TargetRef.Kind {
+	case "DaemonSet":
+		return c.scaleDaemonSet(cd, true)
+	case "StatefulSet":
+		return c.scaleStatefulSet(cd, nil)
+	}
+
 	targetName := cd.Spec.TargetRef.Name
 	dep, err := c.KubeClient.AppsV1().Deployments(cd.Namespace).Get(targetName, metav1.GetOptions{})
 	if err != nil {
@@ -221,7 +252,7 @@ func (c *Deployer) createPrimaryDeployment(cd *flaggerv1.Canary) (string, map[st
 		return "", nil, err
 	}
 
-	label, err := c.getSelectorLabel(canaryDep)
+	label, err := c.getSelectorLabel(canaryDep.Spec.Selector)
 	if err != nil {
 		return "", nil, fmt.Errorf("invalid label selector! Deployment %s.%s spec.selector.matchLabels must contain selector 'app: %s'",
 			targetName, cd.Namespace, targetName)
@@ -229,7 +260,7 @@ func (c *Deployer) createPrimaryDeployment(cd *flaggerv1.Canary) (string, map[st
 
 	var ports map[string]int32
 	if cd.Spec.Service.PortDiscovery {
-		p, err := c.getPorts(cd, canaryDep)
+		p, err := c.getPorts(cd, canaryDep.Spec.Template.Spec.Containers)
 		if err != nil {
 			return "", nil, fmt.Errorf("port discovery failed with error: %v", err)
 		}
@@ -405,9 +436,13 @@ func (c *Deployer) makeAnnotations(annotations map[string]string) (map[string]st
 }
 
 // getSelectorLabel returns the selector match label
-func (c *Deployer) getSelectorLabel(deployment *appsv1.Deployment) (string, error) {
+func (c *Deployer) getSelectorLabel(selector *metav1.LabelSelector) (string, error) {
+	if selector == nil {
+		return "", fmt.Errorf("selector not found")
+	}
+
 	for _, l := range c.Labels {
-		if _, ok := deployment.Spec.Selector.MatchLabels[l]; ok {
+		if _, ok := selector.MatchLabels[l]; ok {
 			return l, nil
 		}
 	}
@@ -421,10 +456,10 @@ var sidecars = map[string]bool{
 }
 
 // getPorts returns a list of all container ports
-func (c *Deployer) getPorts(cd *flaggerv1.Canary, deployment *appsv1.Deployment) (map[string]int32, error) {
+func (c *Deployer) getPorts(cd *flaggerv1.Canary, containers []corev1.Container) (map[string]int32, error) {
 	ports := make(map[string]int32)
 
-	for _, container := range deployment.Spec.Template.Spec.Containers {
+	for _, container := range containers {
 		// exclude service mesh proxies based on container name
 		if _, ok := sidecars[container.Name]; ok {
 			continue
@@ -459,6 +494,41 @@ func (c *Deployer) getPorts(cd *flaggerv1.Canary, deployment *appsv1.Deployment)
 	return ports, nil
 }
 
+// getTargetTemplate returns the pod template of the canary target workload
+// without the fields managed by Flagger
+func getTargetTemplate(kubeClient kubernetes.Interface, cd *flaggerv1.Canary) (*corev1.PodTemplateSpec, error) {
+	targetName := cd.Spec.TargetRef.Name
+	switch cd.Spec.TargetRef.Kind {
+	case "DaemonSet":
+		ds, err := kubeClient.AppsV1().DaemonSets(cd.Namespace).Get(targetName, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil, fmt.Errorf("daemonset %s.%s not found", targetName, cd.Namespace)
+			}
+			return nil, fmt.Errorf("daemonset %s.%s query error %v", targetName, cd.Namespace, err)
+		}
+		return makeDaemonSetTemplate(ds.Spec.Template), nil
+	case "StatefulSet":
+		sts, err := kubeClient.AppsV1().StatefulSets(cd.Namespace).Get(targetName, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil, fmt.Errorf("statefulset %s.%s not found", targetName, cd.Namespace)
+			}
+			return nil, fmt.Errorf("statefulset %s.%s query error %v", targetName, cd.Namespace, err)
+		}
+		return &sts.Spec.Template, nil
+	default:
+		dep, err := kubeClient.AppsV1().Deployments(cd.Namespace).Get(targetName, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil, fmt.Errorf("deployment %s.%s not found", targetName, cd.Namespace)
+			}
+			return nil, fmt.Errorf("deployment %s.%s query error %v", targetName, cd.Namespace, err)
+		}
+		return &dep.Spec.Template, nil
+	}
+}
+
 func makePrimaryLabels(labels map[string]string, primaryName string, label string) map[string]string {
 	res := make(map[string]string)
 	for k, v := range labels {
@@ -1,8 +1,11 @@
 package canary
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
@@ -274,6 +277,10 @@ func TestCanaryDeployer_SyncStatus(t *testing.T) {
 		t.Errorf("Got failed checks %v wanted %v", res.Status.FailedChecks, status.FailedChecks)
 	}
 
+	if res.Status.RolloutStartTime.IsZero() {
+		t.Errorf("Got empty rollout start time wanted the progressing time")
+	}
+
 	if res.Status.TrackedConfigs == nil {
 		t.Fatalf("Status tracking configs are empty")
 	}
@@ -302,3 +309,197 @@ func TestCanaryDeployer_Scale(t *testing.T) {
 		t.Errorf("Got replicas %v wanted %v", *c.Spec.Replicas, 2)
 	}
 }
+
+func TestCanaryDeployer_History(t *testing.T) {
+	mocks := SetupMocks()
+	_, _, err := mocks.deployer.Initialize(mocks.canary, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = mocks.deployer.SyncStatus(mocks.canary, flaggerv1.CanaryStatus{Phase: flaggerv1.CanaryPhaseProgressing})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cd, err := mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = mocks.deployer.SetStatusWeight(cd, 10)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cd, err = mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = mocks.deployer.SetStatusHalted(cd, 1, "request-success-rate", "")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cd, err = mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = mocks.deployer.SyncStatus(cd, flaggerv1.CanaryStatus{Phase: flaggerv1.CanaryPhaseFailed})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	res, err := mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(res.Status.History) != 1 {
+		t.Fatalf("Got history length %v wanted %v", len(res.Status.History), 1)
+	}
+
+	r := res.Status.History[0]
+	if r.Revision != res.Status.LastAppliedSpec {
+		t.Errorf("Got revision %v wanted %v", r.Revision, res.Status.LastAppliedSpec)
+	}
+	if r.Outcome != flaggerv1.CanaryRevisionFailed {
+		t.Errorf("Got outcome %v wanted %v", r.Outcome, flaggerv1.CanaryRevisionFailed)
+	}
+	if r.EndTime == nil {
+		t.Errorf("Got end time nil wanted a value")
+	}
+	if r.CanaryWeight != 10 {
+		t.Errorf("Got weight %v wanted %v", r.CanaryWeight, 10)
+	}
+	if r.FailedChecks != 1 {
+		t.Errorf("Got failed checks %v wanted %v", r.FailedChecks, 1)
+	}
+	if r.FailedMetric != "request-success-rate" {
+		t.Errorf("Got failed metric %v wanted %v", r.FailedMetric, "request-success-rate")
+	}
+}
+
+func TestCanaryDeployer_HistoryLimit(t *testing.T) {
+	var history []flaggerv1.CanaryRevision
+	for i := 0; i < maxStatusHistory+5; i++ {
+		history = startRevision(history, fmt.Sprintf("%d", i))
+	}
+
+	if len(history) != maxStatusHistory {
+		t.Fatalf("Got history length %v wanted %v", len(history), maxStatusHistory)
+	}
+
+	if history[0].Outcome != flaggerv1.CanaryRevisionSuperseded {
+		t.Errorf("Got outcome %v wanted %v", history[0].Outcome, flaggerv1.CanaryRevisionSuperseded)
+	}
+
+	last := history[len(history)-1]
+	if last.Revision != fmt.Sprintf("%d", maxStatusHistory+4) || last.EndTime != nil {
+		t.Errorf("Got last revision %v wanted %v under analysis", last.Revision, maxStatusHistory+4)
+	}
+}
+
+func TestCanaryDeployer_StatusConditions(t *testing.T) {
+	mocks := SetupMocks()
+	_, _, err := mocks.deployer.Initialize(mocks.canary, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	getCondition := func(conditionType flaggerv1.CanaryConditionType) *flaggerv1.CanaryCondition {
+		res, err := mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		return mocks.deployer.getStatusCondition(res.Status, conditionType)
+	}
+
+	err = mocks.deployer.SetStatusPhase(mocks.canary, flaggerv1.CanaryPhaseWaiting)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	paused := getCondition(flaggerv1.PausedType)
+	if paused == nil || paused.Status != corev1.ConditionTrue || paused.Reason != flaggerv1.AwaitingApprovalReason {
+		t.Errorf("Got paused condition %v wanted status %v reason %v", paused, corev1.ConditionTrue, flaggerv1.AwaitingApprovalReason)
+	}
+
+	cd, err := mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = mocks.deployer.SyncStatus(cd, flaggerv1.CanaryStatus{Phase: flaggerv1.CanaryPhaseProgressing})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if c := getCondition(flaggerv1.PausedType); c.Status != corev1.ConditionFalse {
+		t.Errorf("Got paused status %v wanted %v", c.Status, corev1.ConditionFalse)
+	}
+	if c := getCondition(flaggerv1.ProgressingType); c.Status != corev1.ConditionTrue {
+		t.Errorf("Got progressing status %v wanted %v", c.Status, corev1.ConditionTrue)
+	}
+	if c := getCondition(flaggerv1.ReadyType); c.Status != corev1.ConditionUnknown {
+		t.Errorf("Got ready status %v wanted %v", c.Status, corev1.ConditionUnknown)
+	}
+
+	cd, err = mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	rolledBack := flaggerv1.CanaryCondition{
+		Type:   flaggerv1.RolledBackType,
+		Status: corev1.ConditionTrue,
+		Reason: flaggerv1.WebhookRejectedReason,
+	}
+	err = mocks.deployer.SyncStatus(cd, flaggerv1.CanaryStatus{
+		Phase:      flaggerv1.CanaryPhaseFailed,
+		Conditions: []flaggerv1.CanaryCondition{rolledBack},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if c := getCondition(flaggerv1.RolledBackType); c.Status != corev1.ConditionTrue || c.Reason != flaggerv1.WebhookRejectedReason {
+		t.Errorf("Got rolled back condition %v wanted reason %v", c, flaggerv1.WebhookRejectedReason)
+	}
+	if c := getCondition(flaggerv1.ReadyType); c.Status != corev1.ConditionFalse {
+		t.Errorf("Got ready status %v wanted %v", c.Status, corev1.ConditionFalse)
+	}
+	if c := getCondition(flaggerv1.PromotedType); c.Status != corev1.ConditionFalse {
+		t.Errorf("Got promoted status %v wanted %v", c.Status, corev1.ConditionFalse)
+	}
+
+	// a new message with the same status and reason replaces the condition and keeps the transition time
+	cd, err = mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	transitionTime := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	for i := range cd.Status.Conditions {
+		if cd.Status.Conditions[i].Type == flaggerv1.RolledBackType {
+			cd.Status.Conditions[i].LastTransitionTime = transitionTime
+			cd.Status.Conditions[i].LastUpdateTime = transitionTime
+		}
+	}
+	rolledBack.Message = "Canary analysis failed, webhook load-test rejected the rollout."
+	if err := mocks.deployer.SetStatusCondition(cd, rolledBack); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	c := getCondition(flaggerv1.RolledBackType)
+	if c.Message != rolledBack.Message {
+		t.Errorf("Got rolled back message %q wanted %q", c.Message, rolledBack.Message)
+	}
+	if !c.LastTransitionTime.Equal(&transitionTime) {
+		t.Errorf("Got rolled back transition time %v wanted %v", c.LastTransitionTime, transitionTime)
+	}
+	if !c.LastUpdateTime.After(transitionTime.Time) {
+		t.Errorf("Got rolled back update time %v wanted after %v", c.LastUpdateTime, transitionTime)
+	}
+}
@@ -25,13 +25,19 @@ type Mocks struct {
 }
 
 func SetupMocks() Mocks {
+	return SetupMocksWithCanary(newTestCanary())
+}
+
+// SetupMocksWithCanary registers the canary instead of the default deployment canary
+func SetupMocksWithCanary(canary *flaggerv1.Canary) Mocks {
 	// init canary
-	canary := newTestCanary()
 	flaggerClient := fakeFlagger.NewSimpleClientset(canary)
 
 	// init kube clientset and register mock objects
 	kubeClient := fake.NewSimpleClientset(
 		newTestDeployment(),
+		newTestDaemonSet(),
+		newTestStatefulSet(),
 		newTestHPA(),
 		NewTestConfigMap(),
 		NewTestConfigMapEnv(),
@@ -214,6 +220,28 @@ func newTestCanary() *flaggerv1.Canary {
 	return cd
 }
 
+func newTestDaemonSetCanary() *flaggerv1.Canary {
+	cd := newTestCanary()
+	cd.Spec.TargetRef = hpav1.CrossVersionObjectReference{
+		Name:       "podinfo-ds",
+		APIVersion: "apps/v1",
+		Kind:       "DaemonSet",
+	}
+	cd.Spec.AutoscalerRef = nil
+	return cd
+}
+
+func newTestStatefulSetCanary() *flaggerv1.Canary {
+	cd := newTestCanary()
+	cd.Spec.TargetRef = hpav1.CrossVersionObjectReference{
+		Name:       "podinfo-sts",
+		APIVersion: "apps/v1",
+		Kind:       "StatefulSet",
+	}
+	cd.Spec.AutoscalerRef = nil
+	return cd
+}
+
 func newTestDeployment() *appsv1.Deployment {
 	d := &appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.String()},
@@ -470,3 +498,119 @@ func newTestHPA() *hpav2.HorizontalPodAutoscaler {
 
 	return h
 }
+
+func newTestDaemonSet() *appsv1.DaemonSet {
+	d := &appsv1.DaemonSet{
+		TypeMeta: metav1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "podinfo-ds",
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"name": "podinfo-ds",
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"name": "podinfo-ds",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "podinfo",
+							Image: "quay.io/stefanprodan/podinfo:1.2.0",
+							Command: []string{
+								"./podinfo",
+								"--port=9898",
+							},
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "http",
+									ContainerPort: 9898,
+									Protocol:      corev1.ProtocolTCP,
+								},
+							},
+							EnvFrom: []corev1.EnvFromSource{
+								{
+									ConfigMapRef: &corev1.ConfigMapEnvSource{
+										LocalObjectReference: corev1.LocalObjectReference{
+											Name: "podinfo-config-all-env",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return d
+}
+
+func newTestStatefulSet() *appsv1.StatefulSet {
+	s := &appsv1.StatefulSet{
+		TypeMeta: metav1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "podinfo-sts",
+		},
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName: "podinfo-sts",
+			Replicas:    int32p(2),
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+				Type: appsv1.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{
+					Partition: int32p(1),
+				},
+			},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": "podinfo-sts",
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": "podinfo-sts",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "podinfo",
+							Image: "quay.io/stefanprodan/podinfo:1.2.0",
+							Command: []string{
+								"./podinfo",
+								"--port=9898",
+							},
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "http",
+									ContainerPort: 9898,
+									Protocol:      corev1.ProtocolTCP,
+								},
+							},
+							EnvFrom: []corev1.EnvFromSource{
+								{
+									SecretRef: &corev1.SecretEnvSource{
+										LocalObjectReference: corev1.LocalObjectReference{
+											Name: "podinfo-secret-all-env",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return s
+}
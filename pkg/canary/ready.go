@@ -15,6 +15,13 @@ import (
 // the deployment is in the middle of a rolling update or if the pods are unhealthy
 // it will return a non retriable error if the rolling update is stuck
 func (c *Deployer) IsPrimaryReady(cd *flaggerv1.Canary) (bool, error) {
+	switch cd.Spec.TargetRef.Kind {
+	case "DaemonSet":
+		return c.isPrimaryDaemonSetReady(cd)
+	case "StatefulSet":
+		return c.isPrimaryStatefulSetReady(cd)
+	}
+
 	primaryName := fmt.Sprintf("%s-primary", cd.Spec.TargetRef.Name)
 	primary, err := c.KubeClient.AppsV1().Deployments(cd.Namespace).Get(primaryName, metav1.GetOptions{})
 	if err != nil {
@@ -40,6 +47,13 @@ func (c *Deployer) IsPrimaryReady(cd *flaggerv1.Canary) (bool, error) {
 // the deployment is in the middle of a rolling update or if the pods are unhealthy
 // it will return a non retriable error if the rolling update is stuck
 func (c *Deployer) IsCanaryReady(cd *flaggerv1.Canary) (bool, error) {
+	switch cd.Spec.TargetRef.Kind {
+	case "DaemonSet":
+		return c.isCanaryDaemonSetReady(cd)
+	case "StatefulSet":
+		return c.isCanaryStatefulSetReady(cd)
+	}
+
 	targetName := cd.Spec.TargetRef.Name
 	canary, err := c.KubeClient.AppsV1().Deployments(cd.Namespace).Get(targetName, metav1.GetOptions{})
 	if err != nil {
@@ -99,6 +113,18 @@ func (c *Deployer) isDeploymentReady(deployment *appsv1.Deployment, deadline int
 	return true, nil
 }
 
+// hasProgressDeadlineExceeded returns true if the rollout started more than the progress deadline ago,
+// it's used for workload kinds that don't report a progressing condition (DaemonSets and StatefulSets)
+func hasProgressDeadlineExceeded(cd *flaggerv1.Canary) bool {
+	from := cd.Status.RolloutStartTime
+	if from.IsZero() {
+		return false
+	}
+
+	delta := time.Duration(cd.GetProgressDeadlineSeconds()) * time.Second
+	return from.Add(delta).Before(time.Now())
+}
+
 func (c *Deployer) getDeploymentCondition(
 	status appsv1.DeploymentStatus,
 	conditionType appsv1.DeploymentConditionType,
@@ -0,0 +1,308 @@
+package canary
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+)
+
+// createPrimaryStatefulSet creates the primary statefulset, secrets and config maps
+// and returns the pod selector label and container ports
+func (c *Deployer) createPrimaryStatefulSet(cd *flaggerv1.Canary) (string, map[string]int32, error) {
+	targetName := cd.Spec.TargetRef.Name
+	primaryName := fmt.Sprintf("%s-primary", cd.Spec.TargetRef.Name)
+
+	canarySts, err := c.KubeClient.AppsV1().StatefulSets(cd.Namespace).Get(targetName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil, fmt.Errorf("statefulset %s.%s not found, retrying", targetName, cd.Namespace)
+		}
+		return "", nil, err
+	}
+
+	label, err := c.getSelectorLabel(canarySts.Spec.Selector)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid label selector! StatefulSet %s.%s spec.selector.matchLabels must contain selector 'app: %s'",
+			targetName, cd.Namespace, targetName)
+	}
+
+	var ports map[string]int32
+	if cd.Spec.Service.PortDiscovery {
+		p, err := c.getPorts(cd, canarySts.Spec.Template.Spec.Containers)
+		if err != nil {
+			return "", nil, fmt.Errorf("port discovery failed with error: %v", err)
+		}
+		ports = p
+	}
+
+	_, err = c.KubeClient.AppsV1().StatefulSets(cd.Namespace).Get(primaryName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		// create primary secrets and config maps
+		configRefs, err := c.ConfigTracker.GetTargetConfigs(cd)
+		if err != nil {
+			return "", nil, err
+		}
+		if err := c.ConfigTracker.CreatePrimaryConfigs(cd, configRefs); err != nil {
+			return "", nil, err
+		}
+		annotations, err := c.makeAnnotations(canarySts.Spec.Template.Annotations)
+		if err != nil {
+			return "", nil, err
+		}
+
+		replicas := int32(1)
+		if canarySts.Spec.Replicas != nil && *canarySts.Spec.Replicas > 0 {
+			replicas = *canarySts.Spec.Replicas
+		}
+
+		// create primary statefulset
+		primarySts := &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      primaryName,
+				Namespace: cd.Namespace,
+				Labels: map[string]string{
+					label: primaryName,
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(cd, schema.GroupVersionKind{
+						Group:   flaggerv1.SchemeGroupVersion.Group,
+						Version: flaggerv1.SchemeGroupVersion.Version,
+						Kind:    flaggerv1.CanaryKind,
+					}),
+				},
+			},
+			Spec: appsv1.StatefulSetSpec{
+				ServiceName:          canarySts.Spec.ServiceName,
+				PodManagementPolicy:  canarySts.Spec.PodManagementPolicy,
+				RevisionHistoryLimit: canarySts.Spec.RevisionHistoryLimit,
+				UpdateStrategy:       makePrimaryUpdateStrategy(canarySts.Spec.UpdateStrategy),
+				VolumeClaimTemplates: canarySts.Spec.VolumeClaimTemplates,
+				Replicas:             int32p(replicas),
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						label: primaryName,
+					},
+				},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels:      makePrimaryLabels(canarySts.Spec.Template.Labels, primaryName, label),
+						Annotations: annotations,
+					},
+					// update spec with the primary secrets and config maps
+					Spec: c.ConfigTracker.ApplyPrimaryConfigs(canarySts.Spec.Template.Spec, configRefs),
+				},
+			},
+		}
+
+		_, err = c.KubeClient.AppsV1().StatefulSets(cd.Namespace).Create(primarySts)
+		if err != nil {
+			return "", nil, err
+		}
+
+		c.Logger.With("canary", fmt.Sprintf("%s.%s", cd.Name, cd.Namespace)).Infof("StatefulSet %s.%s created", primarySts.GetName(), cd.Namespace)
+	}
+
+	return label, ports, nil
+}
+
+// promoteStatefulSet copies the pod spec, secrets and config maps from the canary statefulset to primary
+func (c *Deployer) promoteStatefulSet(cd *flaggerv1.Canary) error {
+	targetName := cd.Spec.TargetRef.Name
+	primaryName := fmt.Sprintf("%s-primary", targetName)
+
+	canary, err := c.KubeClient.AppsV1().StatefulSets(cd.Namespace).Get(targetName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("statefulset %s.%s not found", targetName, cd.Namespace)
+		}
+		return fmt.Errorf("statefulset %s.%s query error %v", targetName, cd.Namespace, err)
+	}
+
+	label, err := c.getSelectorLabel(canary.Spec.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid label selector! StatefulSet %s.%s spec.selector.matchLabels must contain selector 'app: %s'",
+			targetName, cd.Namespace, targetName)
+	}
+
+	primary, err := c.KubeClient.AppsV1().StatefulSets(cd.Namespace).Get(primaryName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("statefulset %s.%s not found", primaryName, cd.Namespace)
+		}
+		return fmt.Errorf("statefulset %s.%s query error %v", primaryName, cd.Namespace, err)
+	}
+
+	// promote secrets and config maps
+	configRefs, err := c.ConfigTracker.GetTargetConfigs(cd)
+	if err != nil {
+		return err
+	}
+	if err := c.ConfigTracker.CreatePrimaryConfigs(cd, configRefs); err != nil {
+		return err
+	}
+
+	// the statefulset spec is immutable except for replicas, template and update strategy
+	primaryCopy := primary.DeepCopy()
+	primaryCopy.Spec.UpdateStrategy = makePrimaryUpdateStrategy(canary.Spec.UpdateStrategy)
+
+	// update spec with primary secrets and config maps
+	primaryCopy.Spec.Template.Spec = c.ConfigTracker.ApplyPrimaryConfigs(canary.Spec.Template.Spec, configRefs)
+
+	// update pod annotations to ensure a rolling update
+	annotations, err := c.makeAnnotations(canary.Spec.Template.Annotations)
+	if err != nil {
+		return err
+	}
+	primaryCopy.Spec.Template.Annotations = annotations
+
+	primaryCopy.Spec.Template.Labels = makePrimaryLabels(canary.Spec.Template.Labels, primaryName, label)
+
+	// apply update
+	_, err = c.KubeClient.AppsV1().StatefulSets(cd.Namespace).Update(primaryCopy)
+	if err != nil {
+		return fmt.Errorf("updating statefulset %s.%s template spec failed: %v",
+			primaryCopy.GetName(), primaryCopy.Namespace, err)
+	}
+
+	// update HPA
+	if cd.Spec.AutoscalerRef != nil && cd.Spec.AutoscalerRef.Kind == "HorizontalPodAutoscaler" {
+		if err := c.reconcilePrimaryHpa(cd, false); err != nil {
+			return fmt.Errorf("updating HorizontalPodAutoscaler %s.%s failed: %v", primaryName, cd.Namespace, err)
+		}
+	}
+
+	return nil
+}
+
+// scaleStatefulSet sets the canary statefulset replicas,
+// when replicas is nil the statefulset is scaled to its spec replicas (defaults to one replica)
+func (c *Deployer) scaleStatefulSet(cd *flaggerv1.Canary, replicas *int32) error {
+	targetName := cd.Spec.TargetRef.Name
+	sts, err := c.KubeClient.AppsV1().StatefulSets(cd.Namespace).Get(targetName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("statefulset %s.%s not found", targetName, cd.Namespace)
+		}
+		return fmt.Errorf("statefulset %s.%s query error %v", targetName, cd.Namespace, err)
+	}
+
+	if replicas == nil {
+		replicas = int32p(1)
+		if sts.Spec.Replicas != nil && *sts.Spec.Replicas > 0 {
+			replicas = sts.Spec.Replicas
+		}
+	}
+	stsCopy := sts.DeepCopy()
+	stsCopy.Spec.Replicas = replicas
+
+	_, err = c.KubeClient.AppsV1().StatefulSets(sts.Namespace).Update(stsCopy)
+	if err != nil {
+		return fmt.Errorf("scaling %s.%s to %v failed: %v", stsCopy.GetName(), stsCopy.Namespace, *replicas, err)
+	}
+	return nil
+}
+
+// isPrimaryStatefulSetReady checks the primary statefulset rollout status
+func (c *Deployer) isPrimaryStatefulSetReady(cd *flaggerv1.Canary) (bool, error) {
+	primaryName := fmt.Sprintf("%s-primary", cd.Spec.TargetRef.Name)
+	primary, err := c.KubeClient.AppsV1().StatefulSets(cd.Namespace).Get(primaryName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return true, fmt.Errorf("statefulset %s.%s not found", primaryName, cd.Namespace)
+		}
+		return true, fmt.Errorf("statefulset %s.%s query error %v", primaryName, cd.Namespace, err)
+	}
+
+	retriable, err := c.isStatefulSetReady(cd, primary)
+	if err != nil {
+		return retriable, fmt.Errorf("Halt advancement %s.%s %s", primaryName, cd.Namespace, err.Error())
+	}
+
+	if primary.Spec.Replicas != nil && *primary.Spec.Replicas == 0 {
+		return true, fmt.Errorf("Halt %s.%s advancement primary statefulset is scaled to zero",
+			cd.Name, cd.Namespace)
+	}
+	return true, nil
+}
+
+// isCanaryStatefulSetReady checks the canary statefulset rollout status
+func (c *Deployer) isCanaryStatefulSetReady(cd *flaggerv1.Canary) (bool, error) {
+	targetName := cd.Spec.TargetRef.Name
+	canary, err := c.KubeClient.AppsV1().StatefulSets(cd.Namespace).Get(targetName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return true, fmt.Errorf("statefulset %s.%s not found", targetName, cd.Namespace)
+		}
+		return true, fmt.Errorf("statefulset %s.%s query error %v", targetName, cd.Namespace, err)
+	}
+
+	retriable, err := c.isStatefulSetReady(cd, canary)
+	if err != nil {
+		if retriable {
+			return retriable, fmt.Errorf("Halt advancement %s.%s %s", targetName, cd.Namespace, err.Error())
+		} else {
+			return retriable, fmt.Errorf("statefulset does not have minimum availability for more than %vs",
+				cd.GetProgressDeadlineSeconds())
+		}
+	}
+
+	return true, nil
+}
+
+// isStatefulSetReady determines if a statefulset is ready by checking the number of updated and ready replicas,
+// for partitioned rolling updates only the replicas with an ordinal greater or equal to the partition are expected to be updated
+// if the rollout is stuck for more than the canary progress deadline it returns a non retriable error
+func (c *Deployer) isStatefulSetReady(cd *flaggerv1.Canary, statefulSet *appsv1.StatefulSet) (bool, error) {
+	if statefulSet.Generation > statefulSet.Status.ObservedGeneration {
+		return true, fmt.Errorf("waiting for rollout to finish: observed statefulset generation less then desired generation")
+	}
+
+	retriable := !hasProgressDeadlineExceeded(cd)
+
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+
+	if statefulSet.Status.ReadyReplicas < replicas {
+		return retriable, fmt.Errorf("waiting for rollout to finish: %d of %d replicas are ready",
+			statefulSet.Status.ReadyReplicas, replicas)
+	}
+
+	if statefulSet.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType {
+		return true, nil
+	}
+
+	if ru := statefulSet.Spec.UpdateStrategy.RollingUpdate; ru != nil && ru.Partition != nil && *ru.Partition > 0 {
+		expected := replicas - *ru.Partition
+		if statefulSet.Status.UpdatedReplicas < expected {
+			return retriable, fmt.Errorf("waiting for partitioned rollout to finish: %d out of %d new replicas have been updated",
+				statefulSet.Status.UpdatedReplicas, expected)
+		}
+		return true, nil
+	}
+
+	if statefulSet.Status.UpdateRevision != statefulSet.Status.CurrentRevision {
+		return retriable, fmt.Errorf("waiting for rollout to finish: %d out of %d new replicas have been updated",
+			statefulSet.Status.UpdatedReplicas, replicas)
+	}
+
+	return true, nil
+}
+
+// makePrimaryUpdateStrategy returns the canary update strategy without the rolling update partition,
+// the primary statefulset must roll out all its replicas when the canary is promoted
+func makePrimaryUpdateStrategy(strategy appsv1.StatefulSetUpdateStrategy) appsv1.StatefulSetUpdateStrategy {
+	res := *strategy.DeepCopy()
+	if res.RollingUpdate != nil {
+		res.RollingUpdate.Partition = nil
+	}
+
+	return res
+}
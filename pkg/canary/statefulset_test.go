@@ -0,0 +1,181 @@
+package canary
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+)
+
+func TestStatefulSet_Sync(t *testing.T) {
+	mocks := SetupMocksWithCanary(newTestStatefulSetCanary())
+	_, _, err := mocks.deployer.Initialize(mocks.canary, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	stsPrimary, err := mocks.kubeClient.AppsV1().StatefulSets("default").Get("podinfo-sts-primary", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	sts := newTestStatefulSet()
+	primaryImage := stsPrimary.Spec.Template.Spec.Containers[0].Image
+	sourceImage := sts.Spec.Template.Spec.Containers[0].Image
+	if primaryImage != sourceImage {
+		t.Errorf("Got image %s wanted %s", primaryImage, sourceImage)
+	}
+
+	if *stsPrimary.Spec.Replicas != *sts.Spec.Replicas {
+		t.Errorf("Got primary replicas %v wanted %v", *stsPrimary.Spec.Replicas, *sts.Spec.Replicas)
+	}
+
+	if stsPrimary.Spec.UpdateStrategy.RollingUpdate.Partition != nil {
+		t.Errorf("Got primary partition %v wanted nil", *stsPrimary.Spec.UpdateStrategy.RollingUpdate.Partition)
+	}
+
+	secretPrimary, err := mocks.kubeClient.CoreV1().Secrets("default").Get("podinfo-secret-all-env-primary", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if string(secretPrimary.Data["apiKey"]) != string(NewTestSecretEnv().Data["apiKey"]) {
+		t.Errorf("Got primary secret %s wanted %s", secretPrimary.Data["apiKey"], NewTestSecretEnv().Data["apiKey"])
+	}
+
+	canary, err := mocks.kubeClient.AppsV1().StatefulSets("default").Get("podinfo-sts", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if *canary.Spec.Replicas != 0 {
+		t.Errorf("Got canary replicas %v wanted %v", *canary.Spec.Replicas, 0)
+	}
+}
+
+func TestStatefulSet_Promote(t *testing.T) {
+	mocks := SetupMocksWithCanary(newTestStatefulSetCanary())
+	_, _, err := mocks.deployer.Initialize(mocks.canary, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = mocks.deployer.SyncStatus(mocks.canary, flaggerv1.CanaryStatus{Phase: flaggerv1.CanaryPhaseInitialized})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cd, err := mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	sts2, err := mocks.kubeClient.AppsV1().StatefulSets("default").Get("podinfo-sts", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	sts2.Spec.Template.Spec.Containers[0].Image = "quay.io/stefanprodan/podinfo:1.2.1"
+	_, err = mocks.kubeClient.AppsV1().StatefulSets("default").Update(sts2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	isNew, err := mocks.deployer.HasDeploymentChanged(cd)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if !isNew {
+		t.Errorf("Got %v wanted %v", isNew, true)
+	}
+
+	err = mocks.deployer.ScaleUp(cd)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	canary, err := mocks.kubeClient.AppsV1().StatefulSets("default").Get("podinfo-sts", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if *canary.Spec.Replicas != 1 {
+		t.Errorf("Got canary replicas %v wanted %v", *canary.Spec.Replicas, 1)
+	}
+
+	err = mocks.deployer.Promote(cd)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	stsPrimary, err := mocks.kubeClient.AppsV1().StatefulSets("default").Get("podinfo-sts-primary", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	primaryImage := stsPrimary.Spec.Template.Spec.Containers[0].Image
+	sourceImage := sts2.Spec.Template.Spec.Containers[0].Image
+	if primaryImage != sourceImage {
+		t.Errorf("Got image %s wanted %s", primaryImage, sourceImage)
+	}
+}
+
+func TestStatefulSet_IsReady(t *testing.T) {
+	mocks := SetupMocksWithCanary(newTestStatefulSetCanary())
+	_, _, err := mocks.deployer.Initialize(mocks.canary, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, err = mocks.deployer.IsPrimaryReady(mocks.canary)
+	if err == nil {
+		t.Errorf("Expected primary readiness check to fail")
+	}
+
+	sts, err := mocks.kubeClient.AppsV1().StatefulSets("default").Get("podinfo-sts", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	sts.Spec.Replicas = int32p(2)
+	sts.Status.ReadyReplicas = 2
+	sts.Status.UpdatedReplicas = 0
+	_, err = mocks.kubeClient.AppsV1().StatefulSets("default").Update(sts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, err = mocks.deployer.IsCanaryReady(mocks.canary)
+	if err == nil {
+		t.Errorf("Expected canary readiness check to fail, partition replicas are not updated")
+	}
+
+	sts.Status.UpdatedReplicas = 1
+	_, err = mocks.kubeClient.AppsV1().StatefulSets("default").Update(sts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, err = mocks.deployer.IsCanaryReady(mocks.canary)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// the progress deadline is measured from the rollout start and not from the last status update
+	sts.Status.ReadyReplicas = 1
+	_, err = mocks.kubeClient.AppsV1().StatefulSets("default").Update(sts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	mocks.canary.Status.RolloutStartTime = metav1.NewTime(time.Now().Add(-time.Hour))
+	mocks.canary.Status.LastTransitionTime = metav1.Now()
+
+	retriable, err := mocks.deployer.IsCanaryReady(mocks.canary)
+	if err == nil {
+		t.Errorf("Expected canary readiness check to fail, replicas are not ready")
+	}
+	if retriable {
+		t.Errorf("Got retriable %v wanted %v after the progress deadline", retriable, false)
+	}
+}
@@ -2,25 +2,25 @@ package canary
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/mitchellh/hashstructure"
 	ex "github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/util/retry"
 
 	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
 )
 
+// maxStatusHistory is the number of analysed revisions kept in the canary status
+const maxStatusHistory = 10
+
 // SyncStatus encodes the canary pod spec and updates the canary status
 func (c *Deployer) SyncStatus(cd *flaggerv1.Canary, status flaggerv1.CanaryStatus) error {
-	dep, err := c.KubeClient.AppsV1().Deployments(cd.Namespace).Get(cd.Spec.TargetRef.Name, metav1.GetOptions{})
+	template, err := getTargetTemplate(c.KubeClient, cd)
 	if err != nil {
-		if errors.IsNotFound(err) {
-			return fmt.Errorf("deployment %s.%s not found", cd.Spec.TargetRef.Name, cd.Namespace)
-		}
-		return ex.Wrap(err, "SyncStatus deployment query error")
+		return ex.Wrap(err, "SyncStatus")
 	}
 
 	configs, err := c.ConfigTracker.GetConfigRefs(cd)
@@ -28,7 +28,7 @@ func (c *Deployer) SyncStatus(cd *flaggerv1.Canary, status flaggerv1.CanaryStatu
 		return ex.Wrap(err, "SyncStatus configs query error")
 	}
 
-	hash, err := hashstructure.Hash(dep.Spec.Template, nil)
+	hash, err := hashstructure.Hash(*template, nil)
 	if err != nil {
 		return ex.Wrap(err, "SyncStatus hash error")
 	}
@@ -47,14 +47,33 @@ func (c *Deployer) SyncStatus(cd *flaggerv1.Canary, status flaggerv1.CanaryStatu
 		cdCopy.Status.CanaryWeight = status.CanaryWeight
 		cdCopy.Status.FailedChecks = status.FailedChecks
 		cdCopy.Status.Iterations = status.Iterations
+		cdCopy.Status.StepChecks = status.StepChecks
+		cdCopy.Status.LastStepTime = status.LastStepTime
 		cdCopy.Status.LastAppliedSpec = fmt.Sprintf("%d", hash)
 		cdCopy.Status.LastTransitionTime = metav1.Now()
 		cdCopy.Status.TrackedConfigs = configs
 
+		switch status.Phase {
+		case flaggerv1.CanaryPhaseProgressing:
+			cdCopy.Status.History = startRevision(cdCopy.Status.History, cdCopy.Status.LastAppliedSpec)
+			cdCopy.Status.Judgement = nil
+			cdCopy.Status.RolloutStartTime = metav1.Now()
+		case flaggerv1.CanaryPhaseFailed:
+			if r := currentRevision(cdCopy.Status.History); r != nil {
+				r.FailedChecks = cd.Status.FailedChecks
+			}
+			endRevision(cdCopy.Status.History, flaggerv1.CanaryRevisionFailed)
+		}
+
 		if ok, conditions := c.MakeStatusConditions(cd.Status, status.Phase); ok {
 			cdCopy.Status.Conditions = conditions
 		}
 
+		// conditions set by the caller take precedence over the ones derived from the phase
+		if ok, conditions := c.mergeStatusConditions(cdCopy.Status, status.Conditions...); ok {
+			cdCopy.Status.Conditions = conditions
+		}
+
 		_, err = c.FlaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).UpdateStatus(cdCopy)
 		firstTry = false
 		return
@@ -80,6 +99,10 @@ func (c *Deployer) SetStatusFailedChecks(cd *flaggerv1.Canary, val int) error {
 		cdCopy.Status.FailedChecks = val
 		cdCopy.Status.LastTransitionTime = metav1.Now()
 
+		if r := currentRevision(cdCopy.Status.History); r != nil {
+			r.FailedChecks = val
+		}
+
 		_, err = c.FlaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).UpdateStatus(cdCopy)
 		firstTry = false
 		return
@@ -90,7 +113,40 @@ func (c *Deployer) SetStatusFailedChecks(cd *flaggerv1.Canary, val int) error {
 	return nil
 }
 
-// SetStatusWeight updates the canary status weight value
+// SetStatusHalted updates the canary failed checks counter, resets the step checks and records
+// the metric or webhook that halted the advancement in the revision history
+func (c *Deployer) SetStatusHalted(cd *flaggerv1.Canary, val int, metric string, webhook string) error {
+	firstTry := true
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
+		var selErr error
+		if !firstTry {
+			cd, selErr = c.FlaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).Get(cd.GetName(), metav1.GetOptions{})
+			if selErr != nil {
+				return selErr
+			}
+		}
+		cdCopy := cd.DeepCopy()
+		cdCopy.Status.FailedChecks = val
+		cdCopy.Status.StepChecks = 0
+		cdCopy.Status.LastTransitionTime = metav1.Now()
+
+		if r := currentRevision(cdCopy.Status.History); r != nil {
+			r.FailedChecks = val
+			r.FailedMetric = metric
+			r.FailedWebhook = webhook
+		}
+
+		_, err = c.FlaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).UpdateStatus(cdCopy)
+		firstTry = false
+		return
+	})
+	if err != nil {
+		return ex.Wrap(err, "SetStatusHalted")
+	}
+	return nil
+}
+
+// SetStatusWeight updates the canary status weight value and starts a new step
 func (c *Deployer) SetStatusWeight(cd *flaggerv1.Canary, val int) error {
 	firstTry := true
 	err := retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
@@ -103,8 +159,14 @@ func (c *Deployer) SetStatusWeight(cd *flaggerv1.Canary, val int) error {
 		}
 		cdCopy := cd.DeepCopy()
 		cdCopy.Status.CanaryWeight = val
+		cdCopy.Status.StepChecks = 0
+		cdCopy.Status.LastStepTime = metav1.Now()
 		cdCopy.Status.LastTransitionTime = metav1.Now()
 
+		if r := currentRevision(cdCopy.Status.History); r != nil {
+			r.CanaryWeight = val
+		}
+
 		_, err = c.FlaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).UpdateStatus(cdCopy)
 		firstTry = false
 		return
@@ -115,6 +177,56 @@ func (c *Deployer) SetStatusWeight(cd *flaggerv1.Canary, val int) error {
 	return nil
 }
 
+// SetStatusStepChecks updates the number of successful checks of the current step
+func (c *Deployer) SetStatusStepChecks(cd *flaggerv1.Canary, val int) error {
+	firstTry := true
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
+		var selErr error
+		if !firstTry {
+			cd, selErr = c.FlaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).Get(cd.GetName(), metav1.GetOptions{})
+			if selErr != nil {
+				return selErr
+			}
+		}
+		cdCopy := cd.DeepCopy()
+		cdCopy.Status.StepChecks = val
+		cdCopy.Status.LastTransitionTime = metav1.Now()
+
+		_, err = c.FlaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).UpdateStatus(cdCopy)
+		firstTry = false
+		return
+	})
+	if err != nil {
+		return ex.Wrap(err, "SetStatusStepChecks")
+	}
+	return nil
+}
+
+// SetStatusJudgement records the outcome of the last statistical judge run
+func (c *Deployer) SetStatusJudgement(cd *flaggerv1.Canary, judgement *flaggerv1.CanaryJudgement) error {
+	firstTry := true
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
+		var selErr error
+		if !firstTry {
+			cd, selErr = c.FlaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).Get(cd.GetName(), metav1.GetOptions{})
+			if selErr != nil {
+				return selErr
+			}
+		}
+		cdCopy := cd.DeepCopy()
+		cdCopy.Status.Judgement = judgement
+		cdCopy.Status.LastTransitionTime = metav1.Now()
+
+		_, err = c.FlaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).UpdateStatus(cdCopy)
+		firstTry = false
+		return
+	})
+	if err != nil {
+		return ex.Wrap(err, "SetStatusJudgement")
+	}
+	return nil
+}
+
 // SetStatusIterations updates the canary status iterations value
 func (c *Deployer) SetStatusIterations(cd *flaggerv1.Canary, val int) error {
 	firstTry := true
@@ -157,9 +269,20 @@ func (c *Deployer) SetStatusPhase(cd *flaggerv1.Canary, phase flaggerv1.CanaryPh
 		cdCopy.Status.Phase = phase
 		cdCopy.Status.LastTransitionTime = metav1.Now()
 
+		switch phase {
+		case flaggerv1.CanaryPhasePromoting:
+			cdCopy.Status.RolloutStartTime = metav1.Now()
+		case flaggerv1.CanaryPhaseSucceeded:
+			endRevision(cdCopy.Status.History, flaggerv1.CanaryRevisionSucceeded)
+		case flaggerv1.CanaryPhaseFailed:
+			endRevision(cdCopy.Status.History, flaggerv1.CanaryRevisionFailed)
+		}
+
 		if phase != flaggerv1.CanaryPhaseProgressing && phase != flaggerv1.CanaryPhaseWaiting {
 			cdCopy.Status.CanaryWeight = 0
 			cdCopy.Status.Iterations = 0
+			cdCopy.Status.StepChecks = 0
+			cdCopy.Status.LastStepTime = metav1.Time{}
 		}
 
 		// on promotion set primary spec hash
@@ -181,6 +304,60 @@ func (c *Deployer) SetStatusPhase(cd *flaggerv1.Canary, phase flaggerv1.CanaryPh
 	return nil
 }
 
+// currentRevision returns the history record of the revision under analysis
+func currentRevision(history []flaggerv1.CanaryRevision) *flaggerv1.CanaryRevision {
+	if len(history) > 0 && history[len(history)-1].EndTime == nil {
+		return &history[len(history)-1]
+	}
+	return nil
+}
+
+// startRevision appends a record for the revision if it's not already under analysis,
+// a different revision under analysis is marked as superseded
+// and the history is capped to the last maxStatusHistory records
+func startRevision(history []flaggerv1.CanaryRevision, revision string) []flaggerv1.CanaryRevision {
+	if r := currentRevision(history); r != nil {
+		if r.Revision == revision {
+			return history
+		}
+		endRevision(history, flaggerv1.CanaryRevisionSuperseded)
+	}
+
+	history = append(history, flaggerv1.CanaryRevision{
+		Revision:  revision,
+		StartTime: metav1.Now(),
+	})
+	if len(history) > maxStatusHistory {
+		history = history[len(history)-maxStatusHistory:]
+	}
+	return history
+}
+
+// endRevision sets the outcome of the revision under analysis
+func endRevision(history []flaggerv1.CanaryRevision, outcome flaggerv1.CanaryRevisionOutcome) {
+	if r := currentRevision(history); r != nil {
+		now := metav1.Now()
+		r.EndTime = &now
+		r.Outcome = outcome
+	}
+}
+
+// addPausedDuration returns the paused duration of the revision increased by the pause
+// that ended at the given time, the time paused before the analysis started isn't counted
+func addPausedDuration(r *flaggerv1.CanaryRevision, since metav1.Time, now metav1.Time) *metav1.Duration {
+	total := time.Duration(0)
+	if r.PausedDuration != nil {
+		total = r.PausedDuration.Duration
+	}
+	if since.Before(&r.StartTime) {
+		since = r.StartTime
+	}
+	if paused := now.Sub(since.Time); paused > 0 {
+		total += paused
+	}
+	return &metav1.Duration{Duration: total}
+}
+
 // GetStatusCondition returns a condition based on type
 func (c *Deployer) getStatusCondition(status flaggerv1.CanaryStatus, conditionType flaggerv1.CanaryConditionType) *flaggerv1.CanaryCondition {
 	for i := range status.Conditions {
@@ -192,11 +369,118 @@ func (c *Deployer) getStatusCondition(status flaggerv1.CanaryStatus, conditionTy
 	return nil
 }
 
+// SetStatusCondition adds or replaces a canary status condition
+func (c *Deployer) SetStatusCondition(cd *flaggerv1.Canary, condition flaggerv1.CanaryCondition) error {
+	firstTry := true
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
+		var selErr error
+		if !firstTry {
+			cd, selErr = c.FlaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).Get(cd.GetName(), metav1.GetOptions{})
+			if selErr != nil {
+				return selErr
+			}
+		}
+
+		ok, conditions := c.mergeStatusConditions(cd.Status, condition)
+		if !ok {
+			return nil
+		}
+
+		cdCopy := cd.DeepCopy()
+		cdCopy.Status.Conditions = conditions
+
+		_, err = c.FlaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).UpdateStatus(cdCopy)
+		firstTry = false
+		return
+	})
+	if err != nil {
+		return ex.Wrap(err, "SetStatusCondition")
+	}
+	return nil
+}
+
+// SetStatusResumed replaces the suspension or the deployment window hold condition and adds the paused time
+// to the history record of the revision under analysis, the resume time is recorded for the suspensions
+func (c *Deployer) SetStatusResumed(cd *flaggerv1.Canary, condition flaggerv1.CanaryCondition) error {
+	firstTry := true
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
+		var selErr error
+		if !firstTry {
+			cd, selErr = c.FlaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).Get(cd.GetName(), metav1.GetOptions{})
+			if selErr != nil {
+				return selErr
+			}
+		}
+
+		cdCopy := cd.DeepCopy()
+		if r := currentRevision(cdCopy.Status.History); r != nil {
+			now := metav1.Now()
+			if paused := c.getStatusCondition(cd.Status, condition.Type); paused != nil && paused.Status == corev1.ConditionTrue {
+				r.PausedDuration = addPausedDuration(r, paused.LastTransitionTime, now)
+			}
+			if condition.Type == flaggerv1.SuspendedType {
+				r.ResumeTime = &now
+			}
+		}
+
+		if ok, conditions := c.mergeStatusConditions(cd.Status, condition); ok {
+			cdCopy.Status.Conditions = conditions
+		}
+
+		_, err = c.FlaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).UpdateStatus(cdCopy)
+		firstTry = false
+		return
+	})
+	if err != nil {
+		return ex.Wrap(err, "SetStatusResumed")
+	}
+	return nil
+}
+
+// mergeStatusConditions adds or replaces the conditions of the same type,
+// the transition time is kept if the condition status hasn't changed
+// and the update is skipped if the status, the reason and the message haven't changed
+func (c *Deployer) mergeStatusConditions(canaryStatus flaggerv1.CanaryStatus,
+	conditions ...flaggerv1.CanaryCondition) (bool, []flaggerv1.CanaryCondition) {
+	result := make([]flaggerv1.CanaryCondition, len(canaryStatus.Conditions))
+	copy(result, canaryStatus.Conditions)
+
+	changed := false
+	for _, newCondition := range conditions {
+		newCondition.LastUpdateTime = metav1.Now()
+		newCondition.LastTransitionTime = metav1.Now()
+
+		currentCondition := c.getStatusCondition(flaggerv1.CanaryStatus{Conditions: result}, newCondition.Type)
+		if currentCondition == nil {
+			result = append(result, newCondition)
+			changed = true
+			continue
+		}
+
+		if currentCondition.Status == newCondition.Status &&
+			currentCondition.Reason == newCondition.Reason &&
+			currentCondition.Message == newCondition.Message {
+			continue
+		}
+
+		if currentCondition.Status == newCondition.Status {
+			newCondition.LastTransitionTime = currentCondition.LastTransitionTime
+		}
+
+		for i := range result {
+			if result[i].Type == newCondition.Type {
+				result[i] = newCondition
+			}
+		}
+		changed = true
+	}
+
+	return changed, result
+}
+
 // MakeStatusCondition updates the canary status conditions based on canary phase
 func (c *Deployer) MakeStatusConditions(canaryStatus flaggerv1.CanaryStatus,
 	phase flaggerv1.CanaryPhase) (bool, []flaggerv1.CanaryCondition) {
-	currentCondition := c.getStatusCondition(canaryStatus, flaggerv1.PromotedType)
-
 	message := "New deployment detected, starting initialization."
 	status := corev1.ConditionUnknown
 	switch phase {
@@ -226,24 +510,72 @@ func (c *Deployer) MakeStatusConditions(canaryStatus flaggerv1.CanaryStatus,
 		message = "Canary analysis failed, deployment scaled to zero."
 	}
 
-	newCondition := &flaggerv1.CanaryCondition{
-		Type:               flaggerv1.PromotedType,
-		Status:             status,
-		LastUpdateTime:     metav1.Now(),
-		LastTransitionTime: metav1.Now(),
-		Message:            message,
-		Reason:             string(phase),
+	conditions := []flaggerv1.CanaryCondition{
+		{
+			Type:    flaggerv1.PromotedType,
+			Status:  status,
+			Message: message,
+			Reason:  string(phase),
+		},
+	}
+
+	// ready reflects if the primary runs a promoted revision and no analysis is underway
+	ready := corev1.ConditionUnknown
+	switch phase {
+	case flaggerv1.CanaryPhaseInitialized, flaggerv1.CanaryPhaseSucceeded:
+		ready = corev1.ConditionTrue
+	case flaggerv1.CanaryPhaseFailed:
+		ready = corev1.ConditionFalse
 	}
+	conditions = append(conditions, flaggerv1.CanaryCondition{
+		Type:    flaggerv1.ReadyType,
+		Status:  ready,
+		Message: message,
+		Reason:  string(phase),
+	})
 
-	if currentCondition != nil &&
-		currentCondition.Status == newCondition.Status &&
-		currentCondition.Reason == newCondition.Reason {
-		return false, nil
+	progressing := corev1.ConditionFalse
+	switch phase {
+	case flaggerv1.CanaryPhaseProgressing, flaggerv1.CanaryPhasePromoting, flaggerv1.CanaryPhaseFinalising:
+		progressing = corev1.ConditionTrue
 	}
+	conditions = append(conditions, flaggerv1.CanaryCondition{
+		Type:    flaggerv1.ProgressingType,
+		Status:  progressing,
+		Message: message,
+		Reason:  string(phase),
+	})
 
-	if currentCondition != nil && currentCondition.Status == newCondition.Status {
-		newCondition.LastTransitionTime = currentCondition.LastTransitionTime
+	paused := flaggerv1.CanaryCondition{
+		Type:    flaggerv1.PausedType,
+		Status:  corev1.ConditionFalse,
+		Message: message,
+		Reason:  string(phase),
+	}
+	if phase == flaggerv1.CanaryPhaseWaiting {
+		paused.Status = corev1.ConditionTrue
+		paused.Reason = flaggerv1.AwaitingApprovalReason
+	}
+	conditions = append(conditions, paused)
+
+	// the rolled back reason is set by the scheduler, here only the default is provided
+	switch phase {
+	case flaggerv1.CanaryPhaseInitializing, flaggerv1.CanaryPhaseInitialized,
+		flaggerv1.CanaryPhaseProgressing, flaggerv1.CanaryPhaseSucceeded:
+		conditions = append(conditions, flaggerv1.CanaryCondition{
+			Type:    flaggerv1.RolledBackType,
+			Status:  corev1.ConditionFalse,
+			Message: message,
+			Reason:  string(phase),
+		})
+	case flaggerv1.CanaryPhaseFailed:
+		conditions = append(conditions, flaggerv1.CanaryCondition{
+			Type:    flaggerv1.RolledBackType,
+			Status:  corev1.ConditionTrue,
+			Message: message,
+			Reason:  string(phase),
+		})
 	}
 
-	return true, []flaggerv1.CanaryCondition{*newCondition}
+	return c.mergeStatusConditions(canaryStatus, conditions...)
 }
@@ -88,21 +88,17 @@ func (ct *ConfigTracker) getRefFromSecret(name string, namespace string) (*Confi
 	}, nil
 }
 
-// GetTargetConfigs scans the target deployment for Kubernetes ConfigMaps and Secretes
+// GetTargetConfigs scans the target workload for Kubernetes ConfigMaps and Secretes
 // and returns a list of config references
 func (ct *ConfigTracker) GetTargetConfigs(cd *flaggerv1.Canary) (map[string]ConfigRef, error) {
 	res := make(map[string]ConfigRef)
-	targetName := cd.Spec.TargetRef.Name
-	targetDep, err := ct.KubeClient.AppsV1().Deployments(cd.Namespace).Get(targetName, metav1.GetOptions{})
+	template, err := getTargetTemplate(ct.KubeClient, cd)
 	if err != nil {
-		if errors.IsNotFound(err) {
-			return res, fmt.Errorf("deployment %s.%s not found", targetName, cd.Namespace)
-		}
-		return res, fmt.Errorf("deployment %s.%s query error %v", targetName, cd.Namespace, err)
+		return res, err
 	}
 
 	// scan volumes
-	for _, volume := range targetDep.Spec.Template.Spec.Volumes {
+	for _, volume := range template.Spec.Volumes {
 		if cmv := volume.ConfigMap; cmv != nil {
 			config, err := ct.getRefFromConfigMap(cmv.Name, cd.Namespace)
 			if err != nil {
@@ -126,7 +122,7 @@ func (ct *ConfigTracker) GetTargetConfigs(cd *flaggerv1.Canary) (map[string]Conf
 		}
 	}
 	// scan containers
-	for _, container := range targetDep.Spec.Template.Spec.Containers {
+	for _, container := range template.Spec.Containers {
 		// scan env
 		for _, env := range container.Env {
 			if env.ValueFrom != nil {
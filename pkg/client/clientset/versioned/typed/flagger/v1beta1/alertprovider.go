@@ -0,0 +1,174 @@
+/*
+Copyright The Flagger Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"time"
+
+	v1beta1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	scheme "github.com/weaveworks/flagger/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// AlertProvidersGetter has a method to return a AlertProviderInterface.
+// A group's client should implement this interface.
+type AlertProvidersGetter interface {
+	AlertProviders(namespace string) AlertProviderInterface
+}
+
+// AlertProviderInterface has methods to work with AlertProvider resources.
+type AlertProviderInterface interface {
+	Create(*v1beta1.AlertProvider) (*v1beta1.AlertProvider, error)
+	Update(*v1beta1.AlertProvider) (*v1beta1.AlertProvider, error)
+	Delete(name string, options *v1.DeleteOptions) error
+	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
+	Get(name string, options v1.GetOptions) (*v1beta1.AlertProvider, error)
+	List(opts v1.ListOptions) (*v1beta1.AlertProviderList, error)
+	Watch(opts v1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1beta1.AlertProvider, err error)
+	AlertProviderExpansion
+}
+
+// alertProviders implements AlertProviderInterface
+type alertProviders struct {
+	client rest.Interface
+	ns     string
+}
+
+// newAlertProviders returns a AlertProviders
+func newAlertProviders(c *FlaggerV1beta1Client, namespace string) *alertProviders {
+	return &alertProviders{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the alertProvider, and returns the corresponding alertProvider object, and an error if there is any.
+func (c *alertProviders) Get(name string, options v1.GetOptions) (result *v1beta1.AlertProvider, err error) {
+	result = &v1beta1.AlertProvider{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("alertproviders").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of AlertProviders that match those selectors.
+func (c *alertProviders) List(opts v1.ListOptions) (result *v1beta1.AlertProviderList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1beta1.AlertProviderList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("alertproviders").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested alertProviders.
+func (c *alertProviders) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("alertproviders").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch()
+}
+
+// Create takes the representation of a alertProvider and creates it.  Returns the server's representation of the alertProvider, and an error, if there is any.
+func (c *alertProviders) Create(alertProvider *v1beta1.AlertProvider) (result *v1beta1.AlertProvider, err error) {
+	result = &v1beta1.AlertProvider{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("alertproviders").
+		Body(alertProvider).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a alertProvider and updates it. Returns the server's representation of the alertProvider, and an error, if there is any.
+func (c *alertProviders) Update(alertProvider *v1beta1.AlertProvider) (result *v1beta1.AlertProvider, err error) {
+	result = &v1beta1.AlertProvider{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("alertproviders").
+		Name(alertProvider.Name).
+		Body(alertProvider).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the alertProvider and deletes it. Returns an error if one occurs.
+func (c *alertProviders) Delete(name string, options *v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("alertproviders").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *alertProviders) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	var timeout time.Duration
+	if listOptions.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOptions.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("alertproviders").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched alertProvider.
+func (c *alertProviders) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1beta1.AlertProvider, err error) {
+	result = &v1beta1.AlertProvider{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("alertproviders").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
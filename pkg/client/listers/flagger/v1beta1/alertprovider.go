@@ -0,0 +1,94 @@
+/*
+Copyright The Flagger Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1beta1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// AlertProviderLister helps list AlertProviders.
+type AlertProviderLister interface {
+	// List lists all AlertProviders in the indexer.
+	List(selector labels.Selector) (ret []*v1beta1.AlertProvider, err error)
+	// AlertProviders returns an object that can list and get AlertProviders.
+	AlertProviders(namespace string) AlertProviderNamespaceLister
+	AlertProviderListerExpansion
+}
+
+// alertProviderLister implements the AlertProviderLister interface.
+type alertProviderLister struct {
+	indexer cache.Indexer
+}
+
+// NewAlertProviderLister returns a new AlertProviderLister.
+func NewAlertProviderLister(indexer cache.Indexer) AlertProviderLister {
+	return &alertProviderLister{indexer: indexer}
+}
+
+// List lists all AlertProviders in the indexer.
+func (s *alertProviderLister) List(selector labels.Selector) (ret []*v1beta1.AlertProvider, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1beta1.AlertProvider))
+	})
+	return ret, err
+}
+
+// AlertProviders returns an object that can list and get AlertProviders.
+func (s *alertProviderLister) AlertProviders(namespace string) AlertProviderNamespaceLister {
+	return alertProviderNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// AlertProviderNamespaceLister helps list and get AlertProviders.
+type AlertProviderNamespaceLister interface {
+	// List lists all AlertProviders in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*v1beta1.AlertProvider, err error)
+	// Get retrieves the AlertProvider from the indexer for a given namespace and name.
+	Get(name string) (*v1beta1.AlertProvider, error)
+	AlertProviderNamespaceListerExpansion
+}
+
+// alertProviderNamespaceLister implements the AlertProviderNamespaceLister
+// interface.
+type alertProviderNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all AlertProviders in the indexer for a given namespace.
+func (s alertProviderNamespaceLister) List(selector labels.Selector) (ret []*v1beta1.AlertProvider, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1beta1.AlertProvider))
+	})
+	return ret, err
+}
+
+// Get retrieves the AlertProvider from the indexer for a given namespace and name.
+func (s alertProviderNamespaceLister) Get(name string) (*v1beta1.AlertProvider, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1beta1.Resource("alertprovider"), name)
+	}
+	return obj.(*v1beta1.AlertProvider), nil
+}
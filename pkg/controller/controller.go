@@ -2,6 +2,7 @@ package controller
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,10 +21,12 @@ import (
 	"k8s.io/client-go/util/workqueue"
 
 	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+	flaggerv1beta1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
 	"github.com/weaveworks/flagger/pkg/canary"
 	clientset "github.com/weaveworks/flagger/pkg/client/clientset/versioned"
 	flaggerscheme "github.com/weaveworks/flagger/pkg/client/clientset/versioned/scheme"
 	flaggerinformers "github.com/weaveworks/flagger/pkg/client/informers/externalversions/flagger/v1alpha3"
+	flaggerinformersv1beta1 "github.com/weaveworks/flagger/pkg/client/informers/externalversions/flagger/v1beta1"
 	flaggerlisters "github.com/weaveworks/flagger/pkg/client/listers/flagger/v1alpha3"
 	"github.com/weaveworks/flagger/pkg/metrics"
 	"github.com/weaveworks/flagger/pkg/notifier"
@@ -50,6 +53,7 @@ type Controller struct {
 	notifier        notifier.Interface
 	routerFactory   *router.Factory
 	observerFactory *metrics.Factory
+	metricsClients  *sync.Map
 	meshProvider    string
 }
 
@@ -58,6 +62,7 @@ func NewController(
 	istioClient clientset.Interface,
 	flaggerClient clientset.Interface,
 	flaggerInformer flaggerinformers.CanaryInformer,
+	metricTemplateInformer flaggerinformersv1beta1.MetricTemplateInformer,
 	flaggerWindow time.Duration,
 	logger *zap.SugaredLogger,
 	notifier notifier.Interface,
@@ -106,6 +111,7 @@ func NewController(
 		flaggerWindow:   flaggerWindow,
 		deployer:        deployer,
 		observerFactory: observerFactory,
+		metricsClients:  new(sync.Map),
 		recorder:        recorder,
 		notifier:        notifier,
 		routerFactory:   routerFactory,
@@ -134,13 +140,32 @@ func NewController(
 			if ok {
 				ctrl.logger.Infof("Deleting %s.%s from cache", r.Name, r.Namespace)
 				ctrl.canaries.Delete(fmt.Sprintf("%s.%s", r.Name, r.Namespace))
+				ctrl.metricsClients.Delete(fmt.Sprintf("canary/%s.%s", r.Name, r.Namespace))
 			}
 		},
 	})
 
+	metricTemplateInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: ctrl.deleteMetricTemplate,
+	})
+
 	return ctrl
 }
 
+// deleteMetricTemplate removes the metrics client of the deleted MetricTemplate from the cache
+func (c *Controller) deleteMetricTemplate(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	template, ok := obj.(*flaggerv1beta1.MetricTemplate)
+	if !ok {
+		c.logger.Errorf("Event Watch received an invalid object: %#v", obj)
+		return
+	}
+	c.logger.Debugf("Deleting metric template %s.%s from cache", template.Name, template.Namespace)
+	c.metricsClients.Delete(fmt.Sprintf("template/%s.%s", template.Name, template.Namespace))
+}
+
 // Run starts the K8s workers and the canary scheduler
 func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	defer utilruntime.HandleCrash()
@@ -222,6 +247,7 @@ func (c *Controller) syncHandler(key string) error {
 			cdCopy := cd.DeepCopy()
 			cdCopy.Status.Conditions = conditions
 			cdCopy.Status.LastTransitionTime = metav1.Now()
+			cdCopy.Status.RolloutStartTime = cdCopy.Status.LastTransitionTime
 			cdCopy.Status.Phase = flaggerv1.CanaryPhaseInitializing
 			_, err := c.flaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).UpdateStatus(cdCopy)
 			if err != nil {
@@ -272,8 +298,10 @@ func (c *Controller) recordEventWarningf(r *flaggerv1.Canary, template string, a
 	c.eventRecorder.Event(r, corev1.EventTypeWarning, "Synced", fmt.Sprintf(template, args...))
 }
 
+// sendNotification posts the message to the alert providers referenced by the canary,
+// when the canary has no alerts the message goes to the global notifier
 func (c *Controller) sendNotification(cd *flaggerv1.Canary, message string, metadata bool, warn bool) {
-	if c.notifier == nil {
+	if c.notifier == nil && len(cd.Spec.CanaryAnalysis.Alerts) == 0 {
 		return
 	}
 
@@ -295,7 +323,12 @@ func (c *Controller) sendNotification(cd *flaggerv1.Canary, message string, meta
 			},
 		)
 
-		if cd.Spec.CanaryAnalysis.StepWeight > 0 {
+		if len(cd.Spec.CanaryAnalysis.StepWeights) > 0 {
+			fields = append(fields, notifier.Field{
+				Name:  "Traffic routing",
+				Value: fmt.Sprintf("Weight steps: %s", strings.Trim(fmt.Sprint(cd.Spec.CanaryAnalysis.StepWeights), "[]")),
+			})
+		} else if cd.Spec.CanaryAnalysis.StepWeight > 0 {
 			fields = append(fields, notifier.Field{
 				Name: "Traffic routing",
 				Value: fmt.Sprintf("Weight step: %v max: %v",
@@ -314,10 +347,82 @@ func (c *Controller) sendNotification(cd *flaggerv1.Canary, message string, meta
 			})
 		}
 	}
-	err := c.notifier.Post(cd.Name, cd.Namespace, message, fields, warn)
+
+	if len(cd.Spec.CanaryAnalysis.Alerts) == 0 {
+		err := c.notifier.Post(cd.Name, cd.Namespace, message, fields, warn)
+		if err != nil {
+			c.logger.Error(err)
+		}
+		return
+	}
+
+	for _, alert := range cd.Spec.CanaryAnalysis.Alerts {
+		// error alerts receive only the failed analysis notifications
+		if alert.Severity == flaggerv1.AlertSeverityError && !warn {
+			continue
+		}
+
+		client, err := c.alertNotifier(cd, alert)
+		if err != nil {
+			c.logger.With("canary", fmt.Sprintf("%s.%s", cd.Name, cd.Namespace)).
+				Errorf("Alert %s notifier error %v", alert.Name, err)
+			continue
+		}
+
+		err = client.Post(cd.Name, cd.Namespace, message, fields, warn)
+		if err != nil {
+			c.logger.Error(err)
+		}
+	}
+}
+
+// alertNotifier builds the notifier of the AlertProvider referenced by the canary alert,
+// the hook address is read from the provider secret if one is specified
+func (c *Controller) alertNotifier(cd *flaggerv1.Canary, alert flaggerv1.CanaryAlert) (notifier.Interface, error) {
+	namespace := alert.ProviderRef.Namespace
+	if namespace == "" {
+		namespace = cd.Namespace
+	}
+
+	provider, err := c.flaggerClient.FlaggerV1beta1().AlertProviders(namespace).Get(alert.ProviderRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("alert provider %s.%s query error %v", alert.ProviderRef.Name, namespace, err)
+	}
+	// the provider secret is read on behalf of the canary, the other namespaces must be allowed by the provider
+	if !provider.AllowsNamespace(cd.Namespace) {
+		return nil, fmt.Errorf("alert provider %s.%s doesn't allow canaries from namespace %s",
+			provider.Name, namespace, cd.Namespace)
+	}
+
+	address := provider.Spec.Address
+	if provider.Spec.SecretRef != nil {
+		secret, err := c.kubeClient.CoreV1().Secrets(namespace).Get(provider.Spec.SecretRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("alert provider %s.%s secret %s query error %v",
+				provider.Name, namespace, provider.Spec.SecretRef.Name, err)
+		}
+		value, ok := secret.Data[flaggerv1beta1.AlertProviderAddressKey]
+		if !ok {
+			return nil, fmt.Errorf("alert provider %s.%s secret %s doesn't contain the %s key",
+				provider.Name, namespace, provider.Spec.SecretRef.Name, flaggerv1beta1.AlertProviderAddressKey)
+		}
+		address = string(value)
+	}
+
+	username := provider.Spec.Username
+	if username == "" {
+		username = controllerAgentName
+	}
+
+	client, err := notifier.NewFactory(address, username, provider.Spec.Channel).Notifier(provider.Spec.Type)
 	if err != nil {
-		c.logger.Error(err)
+		return nil, fmt.Errorf("alert provider %s.%s %v", provider.Name, namespace, err)
 	}
+	if client == nil {
+		return nil, fmt.Errorf("alert provider %s.%s type %s not supported", provider.Name, namespace, provider.Spec.Type)
+	}
+
+	return client, nil
 }
 
 func int32p(i int32) *int32 {
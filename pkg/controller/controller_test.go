@@ -1,7 +1,10 @@
 package controller
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"sync"
+	"testing"
 	"time"
 
 	"go.uber.org/zap"
@@ -16,6 +19,7 @@ import (
 	"k8s.io/client-go/util/workqueue"
 
 	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+	flaggerv1beta1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
 	istiov1alpha1 "github.com/weaveworks/flagger/pkg/apis/istio/common/v1alpha1"
 	istiov1alpha3 "github.com/weaveworks/flagger/pkg/apis/istio/v1alpha3"
 	"github.com/weaveworks/flagger/pkg/canary"
@@ -99,6 +103,7 @@ func SetupMocks(c *flaggerv1.Canary) Mocks {
 		flaggerWindow:   time.Second,
 		deployer:        deployer,
 		observerFactory: observerFactory,
+		metricsClients:  new(sync.Map),
 		recorder:        metrics.NewRecorder(controllerAgentName, false),
 		routerFactory:   rf,
 	}
@@ -274,6 +279,13 @@ func newTestCanaryMirror() *flaggerv1.Canary {
 	return cd
 }
 
+func newTestCanaryStepWeights() *flaggerv1.Canary {
+	cd := newTestCanary()
+	cd.Spec.CanaryAnalysis.StepWeight = 0
+	cd.Spec.CanaryAnalysis.StepWeights = []int{1, 5, 25, 50}
+	return cd
+}
+
 func newTestCanaryAB() *flaggerv1.Canary {
 	cd := &flaggerv1.Canary{
 		TypeMeta: metav1.TypeMeta{APIVersion: flaggerv1.SchemeGroupVersion.String()},
@@ -587,3 +599,81 @@ func newTestHPA() *hpav2.HorizontalPodAutoscaler {
 
 	return h
 }
+
+func TestController_SendNotificationAlerts(t *testing.T) {
+	var posts []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts = append(posts, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	mocks := SetupMocks(nil)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "on-call-slack", Namespace: "flagger"},
+		Data:       map[string][]byte{"address": []byte(ts.URL + "/on-call")},
+	}
+	if _, err := mocks.kubeClient.CoreV1().Secrets("flagger").Create(secret); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	providers := []*flaggerv1beta1.AlertProvider{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "on-call", Namespace: "flagger"},
+			Spec: flaggerv1beta1.AlertProviderSpec{
+				Type:              "slack",
+				Channel:           "on-call",
+				SecretRef:         &corev1.LocalObjectReference{Name: "on-call-slack"},
+				AllowedNamespaces: []string{"default"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-team", Namespace: "flagger"},
+			Spec: flaggerv1beta1.AlertProviderSpec{
+				Type:      "slack",
+				SecretRef: &corev1.LocalObjectReference{Name: "on-call-slack"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: "default"},
+			Spec: flaggerv1beta1.AlertProviderSpec{
+				Type:    "msteams",
+				Address: ts.URL + "/team",
+			},
+		},
+	}
+	for _, provider := range providers {
+		if _, err := mocks.flaggerClient.FlaggerV1beta1().AlertProviders(provider.Namespace).Create(provider); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	cd := newTestCanary()
+	cd.Spec.CanaryAnalysis.Alerts = []flaggerv1.CanaryAlert{
+		{
+			Name:        "on-call",
+			Severity:    flaggerv1.AlertSeverityError,
+			ProviderRef: flaggerv1.AlertProviderRef{Name: "on-call", Namespace: "flagger"},
+		},
+		{
+			Name:        "team",
+			ProviderRef: flaggerv1.AlertProviderRef{Name: "team"},
+		},
+		// the provider doesn't allow the canary namespace
+		{
+			Name:        "other-team",
+			ProviderRef: flaggerv1.AlertProviderRef{Name: "other-team", Namespace: "flagger"},
+		},
+	}
+
+	mocks.ctrl.sendNotification(cd, "New revision detected", true, false)
+	if len(posts) != 1 || posts[0] != "/team" {
+		t.Errorf("Got posts %v wanted [/team]", posts)
+	}
+
+	posts = nil
+	mocks.ctrl.sendNotification(cd, "Failed checks threshold reached", false, true)
+	if len(posts) != 2 || posts[0] != "/on-call" || posts[1] != "/team" {
+		t.Errorf("Got posts %v wanted [/on-call /team]", posts)
+	}
+}
@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+	"github.com/weaveworks/flagger/pkg/metrics"
+)
+
+// defaultJudgeAlpha is the significance level of the Mann-Whitney U test
+const defaultJudgeAlpha = 0.05
+
+// judgeCanary compares the canary metrics series with the primary ones over the judge window,
+// records the per metric verdicts in the canary status and returns false along with the name
+// of the first metric that failed if the score is under the judge threshold
+func (c *Controller) judgeCanary(r *flaggerv1.Canary, observer metrics.Interface, client metrics.Client) (bool, string) {
+	judge := r.Spec.CanaryAnalysis.Judge
+	alpha := judge.Alpha
+	if alpha == 0 {
+		alpha = defaultJudgeAlpha
+	}
+
+	end := time.Now()
+	start := end.Add(-getJudgeWindow(r))
+	primaryName := fmt.Sprintf("%s-primary", r.Spec.TargetRef.Name)
+
+	judgement := &flaggerv1.CanaryJudgement{Time: metav1.NewTime(end)}
+	passed, judged, failedMetric := 0, 0, ""
+	for _, metric := range r.Spec.CanaryAnalysis.Metrics {
+		if metric.Interval == "" {
+			metric.Interval = r.GetMetricInterval()
+		}
+
+		step, err := time.ParseDuration(judge.Step)
+		if judge.Step == "" {
+			step, err = time.ParseDuration(metric.Interval)
+		}
+		if err != nil {
+			c.recordEventErrorf(r, "Judge step parse error for metric %s: %v", metric.Name, err)
+			return false, metric.Name
+		}
+
+		var query func(name string) ([]float64, error)
+		switch {
+		case metric.TemplateRef != nil:
+			query = func(name string) ([]float64, error) {
+				client, q, err := c.renderMetricTemplate(r, metric, client, name)
+				if err != nil {
+					return nil, err
+				}
+				return client.RunRangeQuery(q, start, end, step)
+			}
+		case metric.Name == "request-success-rate" || metric.Name == "request-duration":
+			query = func(name string) ([]float64, error) {
+				q, err := observer.GetQuery(metric.Name, c.metricTemplateModel(r, metric, name, metric.Interval, ""))
+				if err != nil {
+					return nil, err
+				}
+				return client.RunRangeQuery(q, start, end, step)
+			}
+		default:
+			continue
+		}
+
+		result := c.judgeMetric(r, metric, alpha, query, primaryName)
+		judgement.Metrics = append(judgement.Metrics, result)
+		switch result.Verdict {
+		case flaggerv1.CanaryVerdictPass:
+			passed++
+			judged++
+		case flaggerv1.CanaryVerdictFail:
+			judged++
+			if failedMetric == "" {
+				failedMetric = metric.Name
+			}
+		}
+	}
+
+	// the metrics without data are left out of the score,
+	// the advancement is halted when none of the metrics has data
+	var message string
+	switch {
+	case judged > 0:
+		judgement.Score = float64(passed) / float64(judged) * 100
+	case len(judgement.Metrics) > 0:
+		failedMetric = judgement.Metrics[0].Name
+		message = "judge found no data for any metric"
+	default:
+		judgement.Score = 100
+	}
+	if message == "" && judgement.Score < judge.Threshold {
+		message = fmt.Sprintf("judge score %.2f < %v", judgement.Score, judge.Threshold)
+	}
+
+	if err := c.deployer.SetStatusJudgement(r, judgement); err != nil {
+		c.recordEventWarningf(r, "%v", err)
+	} else {
+		r.Status.Judgement = judgement
+	}
+
+	if message != "" {
+		c.recordEventWarningf(r, "Halt %s.%s advancement %s", r.Name, r.Namespace, message)
+		c.sendNotification(r, fmt.Sprintf("Halt advancement %s", message), false, true)
+		return false, failedMetric
+	}
+
+	return true, ""
+}
+
+// judgeMetric runs the Mann-Whitney U test on the canary and primary series of a metric,
+// the metric fails if the canary series is significantly worse than the primary one
+func (c *Controller) judgeMetric(r *flaggerv1.Canary, metric flaggerv1.CanaryMetric, alpha float64,
+	query func(name string) ([]float64, error), primaryName string) flaggerv1.CanaryMetricJudgement {
+	result := flaggerv1.CanaryMetricJudgement{Name: metric.Name, Verdict: flaggerv1.CanaryVerdictNoData}
+
+	canaryValues, err := query(r.Spec.TargetRef.Name)
+	if err != nil {
+		if !strings.Contains(err.Error(), "no values found") {
+			c.recordEventErrorf(r, "Judge query failed for metric %s: %v", metric.Name, err)
+		}
+		return result
+	}
+	primaryValues, err := query(primaryName)
+	if err != nil {
+		if !strings.Contains(err.Error(), "no values found") {
+			c.recordEventErrorf(r, "Judge query failed for metric %s: %v", metric.Name, err)
+		}
+		return result
+	}
+
+	_, p, err := metrics.MannWhitneyU(canaryValues, primaryValues)
+	if err != nil {
+		return result
+	}
+
+	result.PValue = p
+	result.CanaryMedian = median(canaryValues)
+	result.PrimaryMedian = median(primaryValues)
+	result.Verdict = flaggerv1.CanaryVerdictPass
+
+	if p < alpha {
+		// the threshold range tells if higher or lower values are better
+		thresholdRange := metric.GetThresholdRange()
+		higherIsBetter := thresholdRange.Min != nil && thresholdRange.Max == nil
+		lowerIsBetter := thresholdRange.Max != nil && thresholdRange.Min == nil
+		switch {
+		case higherIsBetter && result.CanaryMedian < result.PrimaryMedian,
+			lowerIsBetter && result.CanaryMedian > result.PrimaryMedian,
+			!higherIsBetter && !lowerIsBetter:
+			result.Verdict = flaggerv1.CanaryVerdictFail
+		}
+	}
+
+	return result
+}
+
+// getJudgeWindow returns the judge window or the time elapsed since the current analysis started,
+// the window starts at the last resume time so that the samples of the suspended canary are left out
+func getJudgeWindow(r *flaggerv1.Canary) time.Duration {
+	window := r.GetAnalysisInterval()
+	if w, err := time.ParseDuration(r.Spec.CanaryAnalysis.Judge.Window); err == nil {
+		window = w
+	} else if n := len(r.Status.History); n > 0 && !r.Status.History[n-1].StartTime.IsZero() {
+		if elapsed := time.Since(r.Status.History[n-1].StartTime.Time); elapsed > 0 {
+			window = elapsed
+		}
+	}
+
+	if n := len(r.Status.History); n > 0 && r.Status.History[n-1].EndTime == nil && r.Status.History[n-1].ResumeTime != nil {
+		if elapsed := time.Since(r.Status.History[n-1].ResumeTime.Time); elapsed > 0 && elapsed < window {
+			window = elapsed
+		}
+	}
+
+	return window
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+)
+
+func TestScheduler_Judge(t *testing.T) {
+	primarySeries := []string{"99.9", "99.8", "99.9", "99.7", "99.8", "99.9", "99.8", "99.9", "99.7", "99.8"}
+	var canarySeries []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/query_range") {
+			w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1545905245.458,"99.9"]}]}}`))
+			return
+		}
+
+		series := canarySeries
+		if strings.Contains(r.URL.Query().Get("query"), `destination_workload=~"podinfo-primary"`) {
+			series = primarySeries
+		}
+		var values []string
+		for i, v := range series {
+			values = append(values, fmt.Sprintf(`[%d,"%s"]`, 1545905245+i*60, v))
+		}
+		json := fmt.Sprintf(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[%s]}]}}`,
+			strings.Join(values, ","))
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	mocks := SetupMocks(nil)
+	tests := []struct {
+		series  []string
+		ok      bool
+		verdict flaggerv1.CanaryVerdict
+	}{
+		{series: primarySeries, ok: true, verdict: flaggerv1.CanaryVerdictPass},
+		{series: []string{"100", "100", "100", "100", "100", "100", "100", "100", "100", "100"}, ok: true, verdict: flaggerv1.CanaryVerdictPass},
+		{series: []string{"99.1", "99.2", "99.0", "99.3", "99.1", "99.2", "99.0", "99.1", "99.2", "99.3"}, ok: false, verdict: flaggerv1.CanaryVerdictFail},
+	}
+
+	for i, test := range tests {
+		canarySeries = test.series
+		cd := newTestCanary()
+		cd.Spec.MetricsServer = ts.URL
+		cd.Spec.CanaryAnalysis.Judge = &flaggerv1.CanaryJudge{Threshold: 100, Window: "10m"}
+		cd.Spec.CanaryAnalysis.Metrics = []flaggerv1.CanaryMetric{
+			{
+				Name:      "request-success-rate",
+				Interval:  "1m",
+				Threshold: 99,
+			},
+		}
+
+		ok, metric, _ := mocks.ctrl.analyseCanary(cd)
+		if ok != test.ok {
+			t.Errorf("Got ok %v wanted %v for series %d", ok, test.ok, i)
+		}
+		if !ok && metric != "request-success-rate" {
+			t.Errorf("Got failed metric %s wanted %s for series %d", metric, "request-success-rate", i)
+		}
+
+		judgement := cd.Status.Judgement
+		if judgement == nil || len(judgement.Metrics) != 1 {
+			t.Fatalf("Got judgement %v wanted one metric for series %d", judgement, i)
+		}
+		if judgement.Metrics[0].Verdict != test.verdict {
+			t.Errorf("Got verdict %s wanted %s for series %d", judgement.Metrics[0].Verdict, test.verdict, i)
+		}
+	}
+}
+
+func TestScheduler_JudgeNoData(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/query_range") {
+			w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1545905245.458,"99.9"]}]}}`))
+			return
+		}
+		w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+	}))
+	defer ts.Close()
+
+	mocks := SetupMocks(nil)
+	cd := newTestCanary()
+	cd.Spec.MetricsServer = ts.URL
+	cd.Spec.CanaryAnalysis.Judge = &flaggerv1.CanaryJudge{Threshold: 50}
+	cd.Spec.CanaryAnalysis.Metrics = []flaggerv1.CanaryMetric{
+		{
+			Name:      "request-success-rate",
+			Interval:  "1m",
+			Threshold: 99,
+		},
+	}
+
+	if ok, _, _ := mocks.ctrl.analyseCanary(cd); ok {
+		t.Errorf("Got ok %v wanted %v", ok, false)
+	}
+
+	c, err := mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if c.Status.Judgement == nil || c.Status.Judgement.Metrics[0].Verdict != flaggerv1.CanaryVerdictNoData {
+		t.Errorf("Got judgement %v wanted verdict %s", c.Status.Judgement, flaggerv1.CanaryVerdictNoData)
+	}
+}
+
+func TestScheduler_JudgeWindowResume(t *testing.T) {
+	cd := newTestCanary()
+	cd.Spec.CanaryAnalysis.Judge = &flaggerv1.CanaryJudge{Threshold: 80}
+	start := metav1.NewTime(time.Now().Add(-time.Hour))
+	cd.Status.History = []flaggerv1.CanaryRevision{{Revision: "1", StartTime: start}}
+
+	if window := getJudgeWindow(cd); window < time.Hour {
+		t.Errorf("Got window %v wanted the time since the analysis started", window)
+	}
+
+	// the samples collected while the canary was suspended are left out
+	resume := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+	cd.Status.History[0].ResumeTime = &resume
+	if window := getJudgeWindow(cd); window < 10*time.Minute || window > 11*time.Minute {
+		t.Errorf("Got window %v wanted the time since the analysis resumed", window)
+	}
+
+	cd.Spec.CanaryAnalysis.Judge.Window = "5m"
+	if window := getJudgeWindow(cd); window != 5*time.Minute {
+		t.Errorf("Got window %v wanted %v", window, 5*time.Minute)
+	}
+}
+
+func TestScheduler_JudgeNoDataScore(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/query_range") {
+			w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1545905245.458,"99.9"]}]}}`))
+			return
+		}
+		if strings.Contains(r.URL.Query().Get("query"), "istio_request_duration") {
+			w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+			return
+		}
+		var values []string
+		for i := 0; i < 10; i++ {
+			values = append(values, fmt.Sprintf(`[%d,"99.9"]`, 1545905245+i*60))
+		}
+		json := fmt.Sprintf(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[%s]}]}}`,
+			strings.Join(values, ","))
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	mocks := SetupMocks(nil)
+	cd := newTestCanary()
+	cd.Spec.MetricsServer = ts.URL
+	cd.Spec.CanaryAnalysis.Judge = &flaggerv1.CanaryJudge{Threshold: 100, Window: "10m"}
+	cd.Spec.CanaryAnalysis.Metrics = []flaggerv1.CanaryMetric{
+		{
+			Name:      "request-success-rate",
+			Interval:  "1m",
+			Threshold: 99,
+		},
+		{
+			Name:      "request-duration",
+			Interval:  "1m",
+			Threshold: 100000,
+		},
+	}
+
+	// the request duration without data is left out of the score
+	if ok, metric, _ := mocks.ctrl.analyseCanary(cd); !ok {
+		t.Errorf("Got ok %v wanted %v failed metric %s", ok, true, metric)
+	}
+
+	judgement := cd.Status.Judgement
+	if judgement == nil || len(judgement.Metrics) != 2 {
+		t.Fatalf("Got judgement %v wanted two metrics", judgement)
+	}
+	if judgement.Score != 100 {
+		t.Errorf("Got score %v wanted %v", judgement.Score, 100)
+	}
+	if judgement.Metrics[1].Verdict != flaggerv1.CanaryVerdictNoData {
+		t.Errorf("Got verdict %s wanted %s", judgement.Metrics[1].Verdict, flaggerv1.CanaryVerdictNoData)
+	}
+}
+
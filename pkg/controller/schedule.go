@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+)
+
+// checkSchedule returns false if the canary advancement should be held because no deployment window
+// is open or a blackout period is in progress, the hold is recorded as a paused condition
+func (c *Controller) checkSchedule(cd *flaggerv1.Canary) bool {
+	reason, message := "", ""
+	if schedule := cd.Spec.CanaryAnalysis.Schedule; schedule != nil {
+		var err error
+		reason, message, err = scheduleHold(schedule, time.Now())
+		if err != nil {
+			c.recordEventWarningf(cd, "Halt %s.%s advancement invalid schedule %v", cd.Name, cd.Namespace, err)
+			return false
+		}
+	}
+
+	pausedReason := ""
+	for _, condition := range cd.Status.Conditions {
+		if condition.Type == flaggerv1.PausedType && condition.Status == corev1.ConditionTrue {
+			pausedReason = condition.Reason
+		}
+	}
+
+	if reason == "" {
+		if pausedReason == flaggerv1.OutsideDeploymentWindowReason || pausedReason == flaggerv1.BlackoutPeriodReason {
+			c.recordEventInfof(cd, "Deployment window open, resuming %s.%s advancement", cd.Name, cd.Namespace)
+			condition := flaggerv1.CanaryCondition{
+				Type:    flaggerv1.PausedType,
+				Status:  corev1.ConditionFalse,
+				Reason:  string(cd.Status.Phase),
+				Message: "Deployment window open.",
+			}
+			if err := c.deployer.SetStatusResumed(cd, condition); err != nil {
+				c.recordEventWarningf(cd, "%v", err)
+			}
+			// the advancement continues on the next run
+			return false
+		}
+		return true
+	}
+
+	if pausedReason != reason {
+		c.recordEventWarningf(cd, "Halt %s.%s advancement %s", cd.Name, cd.Namespace, message)
+		condition := flaggerv1.CanaryCondition{
+			Type:    flaggerv1.PausedType,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: message,
+		}
+		if err := c.deployer.SetStatusCondition(cd, condition); err != nil {
+			c.recordEventWarningf(cd, "%v", err)
+		}
+		c.sendNotification(cd, fmt.Sprintf("Canary advancement is on hold, %s", message), false, false)
+	}
+
+	return false
+}
+
+// scheduleHold returns an empty reason if the advancement is allowed at the given time,
+// otherwise it returns the reason and the message of the hold
+func scheduleHold(schedule *flaggerv1.CanarySchedule, now time.Time) (string, string, error) {
+	location, err := time.LoadLocation(schedule.TimeZone)
+	if err != nil {
+		return "", "", fmt.Errorf("time zone %s error %v", schedule.TimeZone, err)
+	}
+	now = now.In(location)
+
+	for _, blackout := range schedule.Blackouts {
+		if !now.Before(blackout.Start.Time) && now.Before(blackout.End.Time) {
+			return flaggerv1.BlackoutPeriodReason,
+				fmt.Sprintf("blackout period %s ends at %s", blackout.Name, blackout.End.In(location).Format(time.RFC3339)), nil
+		}
+	}
+
+	if len(schedule.Windows) == 0 {
+		return "", "", nil
+	}
+
+	var next time.Time
+	for _, window := range schedule.Windows {
+		start, err := cron.ParseStandard(window.Start)
+		if err != nil {
+			return "", "", fmt.Errorf("window start %s error %v", window.Start, err)
+		}
+		duration, err := time.ParseDuration(window.Duration)
+		if err != nil {
+			return "", "", fmt.Errorf("window duration %s error %v", window.Duration, err)
+		}
+
+		// the window is open if it started during the last duration
+		if !start.Next(now.Add(-duration)).After(now) {
+			return "", "", nil
+		}
+
+		if n := start.Next(now); next.IsZero() || n.Before(next) {
+			next = n
+		}
+	}
+
+	return flaggerv1.OutsideDeploymentWindowReason,
+		fmt.Sprintf("next deployment window opens at %s", next.Format(time.RFC3339)), nil
+}
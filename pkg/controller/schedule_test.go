@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+)
+
+func TestScheduleHold(t *testing.T) {
+	businessHours := []flaggerv1.CanaryWindow{{Start: "0 9 * * 1-5", Duration: "8h"}}
+	holidays := []flaggerv1.CanaryBlackout{
+		{
+			Name:  "holidays",
+			Start: metav1.NewTime(time.Date(2019, 12, 24, 0, 0, 0, 0, time.UTC)),
+			End:   metav1.NewTime(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)),
+		},
+	}
+
+	tests := map[string]struct {
+		schedule flaggerv1.CanarySchedule
+		now      time.Time
+		reason   string
+	}{
+		"inside window": {
+			schedule: flaggerv1.CanarySchedule{Windows: businessHours},
+			// Wednesday
+			now: time.Date(2019, 11, 6, 10, 0, 0, 0, time.UTC),
+		},
+		"after window": {
+			schedule: flaggerv1.CanarySchedule{Windows: businessHours},
+			now:      time.Date(2019, 11, 6, 18, 0, 0, 0, time.UTC),
+			reason:   flaggerv1.OutsideDeploymentWindowReason,
+		},
+		"weekend": {
+			schedule: flaggerv1.CanarySchedule{Windows: businessHours},
+			// Saturday
+			now:    time.Date(2019, 11, 9, 10, 0, 0, 0, time.UTC),
+			reason: flaggerv1.OutsideDeploymentWindowReason,
+		},
+		"time zone": {
+			schedule: flaggerv1.CanarySchedule{Windows: businessHours, TimeZone: "America/New_York"},
+			// 10:00 UTC is 05:00 in New York
+			now:    time.Date(2019, 11, 6, 10, 0, 0, 0, time.UTC),
+			reason: flaggerv1.OutsideDeploymentWindowReason,
+		},
+		"blackout": {
+			schedule: flaggerv1.CanarySchedule{Windows: businessHours, Blackouts: holidays},
+			// Friday
+			now:    time.Date(2019, 12, 27, 10, 0, 0, 0, time.UTC),
+			reason: flaggerv1.BlackoutPeriodReason,
+		},
+		"blackout without windows": {
+			schedule: flaggerv1.CanarySchedule{Blackouts: holidays},
+			now:      time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for name, test := range tests {
+		reason, message, err := scheduleHold(&test.schedule, test.now)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if reason != test.reason {
+			t.Errorf("%s: got reason %q wanted %q (%s)", name, reason, test.reason, message)
+		}
+	}
+
+	_, message, _ := scheduleHold(&flaggerv1.CanarySchedule{Windows: businessHours}, time.Date(2019, 11, 9, 10, 0, 0, 0, time.UTC))
+	if expected := "next deployment window opens at 2019-11-11T09:00:00Z"; message != expected {
+		t.Errorf("Got message %s wanted %s", message, expected)
+	}
+
+	if _, _, err := scheduleHold(&flaggerv1.CanarySchedule{TimeZone: "Mars/Olympus"}, time.Now()); err == nil {
+		t.Errorf("Got no error wanted invalid time zone")
+	}
+}
+
+func TestScheduler_DeploymentWindow(t *testing.T) {
+	mocks := SetupMocks(nil)
+	// init
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	c, err := mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	c.Spec.CanaryAnalysis.Schedule = &flaggerv1.CanarySchedule{
+		Blackouts: []flaggerv1.CanaryBlackout{
+			{
+				Name:  "incident",
+				Start: metav1.NewTime(time.Now().Add(-time.Hour)),
+				End:   metav1.NewTime(time.Now().Add(time.Hour)),
+			},
+		},
+	}
+	_, err = mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Update(c)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// update
+	dep2 := newTestDeploymentV2()
+	_, err = mocks.kubeClient.AppsV1().Deployments("default").Update(dep2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// detect changes
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+	// hold
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	c, err = mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if c.Status.CanaryWeight != 0 {
+		t.Errorf("Got canary weight %v wanted %v", c.Status.CanaryWeight, 0)
+	}
+
+	paused := false
+	for _, condition := range c.Status.Conditions {
+		if condition.Type == flaggerv1.PausedType && condition.Status == "True" &&
+			condition.Reason == flaggerv1.BlackoutPeriodReason {
+			paused = true
+		}
+	}
+	if !paused {
+		t.Errorf("Got conditions %v wanted paused by %s", c.Status.Conditions, flaggerv1.BlackoutPeriodReason)
+	}
+
+	// end the blackout
+	c.Spec.CanaryAnalysis.Schedule = nil
+	_, err = mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Update(c)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// resume
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+	// advance
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	c, err = mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for _, condition := range c.Status.Conditions {
+		if condition.Type == flaggerv1.PausedType && condition.Status != "False" {
+			t.Errorf("Got paused condition %v wanted status False", condition)
+		}
+	}
+
+	if c.Status.CanaryWeight != 10 {
+		t.Errorf("Got canary weight %v wanted %v", c.Status.CanaryWeight, 10)
+	}
+}
@@ -3,10 +3,14 @@ package controller
 import (
 	"fmt"
 	"github.com/weaveworks/flagger/pkg/metrics"
+	"math"
 	"strings"
 	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
 
 	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
 	"github.com/weaveworks/flagger/pkg/router"
@@ -89,6 +93,11 @@ func (c *Controller) advanceCanary(name string, namespace string, skipLivenessCh
 		return
 	}
 
+	// freeze the canary routing, scaling and status while suspended
+	if ok := c.checkSuspended(cd); !ok {
+		return
+	}
+
 	primaryName := fmt.Sprintf("%s-primary", cd.Spec.TargetRef.Name)
 
 	// override the global provider if one is specified in the canary spec
@@ -97,6 +106,13 @@ func (c *Controller) advanceCanary(name string, namespace string, skipLivenessCh
 		provider = cd.Spec.Provider
 	}
 
+	// the scheduling logic uses a copy with the same defaults as the defaulting admission webhook,
+	// the status updates and the notifications keep the canary spec as stored
+	canary := cd.DeepCopy()
+	canary.SetDefaults(c.meshProvider)
+	hasMatch := len(cd.Spec.CanaryAnalysis.Match) > 0
+	hasIterations := cd.Spec.CanaryAnalysis.Iterations > 0
+
 	// create primary deployment and hpa if needed
 	// skip primary check for Istio since the deployment will become ready after the ClusterIP are created
 	skipPrimaryCheck := false
@@ -136,16 +152,33 @@ func (c *Controller) advanceCanary(name string, namespace string, skipLivenessCh
 		return
 	}
 
+	// check if the user aborted the analysis
+	if cd.IsAborted() {
+		if cd.Status.Phase == flaggerv1.CanaryPhaseProgressing || cd.Status.Phase == flaggerv1.CanaryPhaseWaiting {
+			c.recordEventWarningf(cd, "Rolling back %s.%s manual rollback requested", cd.Name, cd.Namespace)
+			c.sendNotification(cd, "Manual rollback requested, canary analysis aborted.", false, true)
+			condition := flaggerv1.CanaryCondition{
+				Type:    flaggerv1.RolledBackType,
+				Status:  corev1.ConditionTrue,
+				Reason:  flaggerv1.ManualRollbackReason,
+				Message: "Canary analysis aborted by the user.",
+			}
+			if ok := c.rollback(cd, meshRouter, condition); !ok {
+				return
+			}
+		} else {
+			c.recordEventInfof(cd, "Ignoring %s.%s abort request, no analysis in progress", cd.Name, cd.Namespace)
+		}
+		c.clearAbort(cd)
+		return
+	}
+
 	// check gates
 	if isApproved := c.runConfirmRolloutHooks(cd); !isApproved {
 		return
 	}
 
-	// set max weight default value to 100%
-	maxWeight := 100
-	if cd.Spec.CanaryAnalysis.MaxWeight > 0 {
-		maxWeight = cd.Spec.CanaryAnalysis.MaxWeight
-	}
+	maxWeight := canary.GetMaxWeight()
 
 	// check primary deployment status
 	if !skipLivenessChecks {
@@ -257,9 +290,9 @@ func (c *Controller) advanceCanary(name string, namespace string, skipLivenessCh
 
 	// check if the number of failed checks reached the threshold
 	if cd.Status.Phase == flaggerv1.CanaryPhaseProgressing &&
-		(!retriable || cd.Status.FailedChecks >= cd.Spec.CanaryAnalysis.Threshold) {
+		(!retriable || cd.Status.FailedChecks >= canary.Spec.CanaryAnalysis.Threshold) {
 
-		if cd.Status.FailedChecks >= cd.Spec.CanaryAnalysis.Threshold {
+		if cd.Status.FailedChecks >= canary.Spec.CanaryAnalysis.Threshold {
 			c.recordEventWarningf(cd, "Rolling back %s.%s failed checks threshold reached %v",
 				cd.Name, cd.Namespace, cd.Status.FailedChecks)
 			c.sendNotification(cd, fmt.Sprintf("Failed checks threshold reached %v", cd.Status.FailedChecks),
@@ -273,52 +306,60 @@ func (c *Controller) advanceCanary(name string, namespace string, skipLivenessCh
 				false, true)
 		}
 
-		// route all traffic back to primary
-		primaryWeight = 100
-		canaryWeight = 0
-		if err := meshRouter.SetRoutes(cd, primaryWeight, canaryWeight, false); err != nil {
-			c.recordEventWarningf(cd, "%v", err)
-			return
-		}
-
-		c.recorder.SetWeight(cd, primaryWeight, canaryWeight)
-		c.recordEventWarningf(cd, "Canary failed! Scaling down %s.%s",
-			cd.Name, cd.Namespace)
+		c.rollback(cd, meshRouter, makeRolledBackCondition(cd, retriable, err))
+		return
+	}
 
-		// shutdown canary
-		if err := c.deployer.Scale(cd, 0); err != nil {
-			c.recordEventWarningf(cd, "%v", err)
+	// end the analysis if it runs for longer than the max duration
+	if cd.Status.Phase == flaggerv1.CanaryPhaseProgressing && maxDurationExceeded(cd, time.Now()) {
+		maxDuration := canary.Spec.CanaryAnalysis.MaxDuration
+		if canary.Spec.CanaryAnalysis.MaxDurationAction == flaggerv1.MaxDurationPromote {
+			c.recordEventWarningf(cd, "Promoting %s.%s analysis max duration %s exceeded",
+				cd.Name, cd.Namespace, maxDuration)
+			c.sendNotification(cd, fmt.Sprintf("Analysis max duration %s exceeded, promoting canary", maxDuration),
+				false, true)
+			c.promoteOnMaxDuration(cd, primaryName)
 			return
 		}
 
-		// mark canary as failed
-		if err := c.deployer.SyncStatus(cd, flaggerv1.CanaryStatus{Phase: flaggerv1.CanaryPhaseFailed, CanaryWeight: 0}); err != nil {
-			c.logger.With("canary", fmt.Sprintf("%s.%s", cd.Name, cd.Namespace)).Errorf("%v", err)
-			return
+		c.recordEventWarningf(cd, "Rolling back %s.%s analysis max duration %s exceeded",
+			cd.Name, cd.Namespace, maxDuration)
+		c.sendNotification(cd, fmt.Sprintf("Analysis max duration %s exceeded", maxDuration),
+			false, true)
+		condition := flaggerv1.CanaryCondition{
+			Type:    flaggerv1.RolledBackType,
+			Status:  corev1.ConditionTrue,
+			Reason:  flaggerv1.MaxDurationExceededReason,
+			Message: fmt.Sprintf("Canary analysis failed, max duration %s exceeded.", maxDuration),
 		}
-
-		c.recorder.SetStatus(cd, flaggerv1.CanaryPhaseFailed)
-		c.runPostRolloutHooks(cd, flaggerv1.CanaryPhaseFailed)
+		c.rollback(cd, meshRouter, condition)
 		return
 	}
 
+	// the deployment windows hold the analysis start, the advancement and the promotion
+	scheduled := c.checkSchedule(cd)
+
 	// check if the canary success rate is above the threshold
 	// skip check if no traffic is routed or mirrored to canary
 	if canaryWeight == 0 && cd.Status.Iterations == 0 &&
-		(cd.Spec.CanaryAnalysis.Mirror == false || mirrored == false) {
+		(canary.Spec.CanaryAnalysis.Mirror == false || mirrored == false) {
+		if !scheduled {
+			return
+		}
+
 		c.recordEventInfof(cd, "Starting canary analysis for %s.%s", cd.Spec.TargetRef.Name, cd.Namespace)
 
 		// run pre-rollout web hooks
-		if ok := c.runPreRolloutHooks(cd); !ok {
-			if err := c.deployer.SetStatusFailedChecks(cd, cd.Status.FailedChecks+1); err != nil {
+		if ok, webhook := c.runPreRolloutHooks(cd); !ok {
+			if err := c.deployer.SetStatusHalted(cd, cd.Status.FailedChecks+1, "", webhook); err != nil {
 				c.recordEventWarningf(cd, "%v", err)
 				return
 			}
 			return
 		}
 	} else {
-		if ok := c.analyseCanary(cd); !ok {
-			if err := c.deployer.SetStatusFailedChecks(cd, cd.Status.FailedChecks+1); err != nil {
+		if ok, metric, webhook := c.analyseCanary(cd); !ok {
+			if err := c.deployer.SetStatusHalted(cd, cd.Status.FailedChecks+1, metric, webhook); err != nil {
 				c.recordEventWarningf(cd, "%v", err)
 				return
 			}
@@ -326,23 +367,27 @@ func (c *Controller) advanceCanary(name string, namespace string, skipLivenessCh
 		}
 	}
 
-	// use blue/green strategy for kubernetes provider
+	// the checks keep running outside the deployment windows so that a failing canary is rolled back
+	if !scheduled {
+		return
+	}
+
+	// blue/green strategy is used for kubernetes provider, the defaults remove the match conditions
+	// and set the iterations, the warnings tell the users that their spec was rewritten
 	if provider == "kubernetes" {
-		if len(cd.Spec.CanaryAnalysis.Match) > 0 {
+		if hasMatch {
 			c.recordEventWarningf(cd, "A/B testing is not supported when using the kubernetes provider")
-			cd.Spec.CanaryAnalysis.Match = nil
 		}
-		if cd.Spec.CanaryAnalysis.Iterations < 1 {
+		if !hasIterations {
 			c.recordEventWarningf(cd, "Progressive traffic is not supported when using the kubernetes provider")
-			c.recordEventWarningf(cd, "Setting canaryAnalysis.iterations: 10")
-			cd.Spec.CanaryAnalysis.Iterations = 10
+			c.recordEventWarningf(cd, "Setting canaryAnalysis.iterations: %d", canary.Spec.CanaryAnalysis.Iterations)
 		}
 	}
 
 	// strategy: A/B testing
-	if len(cd.Spec.CanaryAnalysis.Match) > 0 && cd.Spec.CanaryAnalysis.Iterations > 0 {
+	if len(canary.Spec.CanaryAnalysis.Match) > 0 && canary.Spec.CanaryAnalysis.Iterations > 0 {
 		// route traffic to canary and increment iterations
-		if cd.Spec.CanaryAnalysis.Iterations > cd.Status.Iterations {
+		if canary.Spec.CanaryAnalysis.Iterations > cd.Status.Iterations {
 			if err := meshRouter.SetRoutes(cd, 0, 100, false); err != nil {
 				c.recordEventWarningf(cd, "%v", err)
 				return
@@ -354,7 +399,7 @@ func (c *Controller) advanceCanary(name string, namespace string, skipLivenessCh
 				return
 			}
 			c.recordEventInfof(cd, "Advance %s.%s canary iteration %v/%v",
-				cd.Name, cd.Namespace, cd.Status.Iterations+1, cd.Spec.CanaryAnalysis.Iterations)
+				cd.Name, cd.Namespace, cd.Status.Iterations+1, canary.Spec.CanaryAnalysis.Iterations)
 			return
 		}
 
@@ -364,7 +409,7 @@ func (c *Controller) advanceCanary(name string, namespace string, skipLivenessCh
 		}
 
 		// promote canary - max iterations reached
-		if cd.Spec.CanaryAnalysis.Iterations == cd.Status.Iterations {
+		if canary.Spec.CanaryAnalysis.Iterations == cd.Status.Iterations {
 			c.recordEventInfof(cd, "Copying %s.%s template spec to %s.%s",
 				cd.Spec.TargetRef.Name, cd.Namespace, primaryName, cd.Namespace)
 			if err := c.deployer.Promote(cd); err != nil {
@@ -384,12 +429,12 @@ func (c *Controller) advanceCanary(name string, namespace string, skipLivenessCh
 	}
 
 	// strategy: Blue/Green
-	if cd.Spec.CanaryAnalysis.Iterations > 0 {
+	if canary.Spec.CanaryAnalysis.Iterations > 0 {
 		// increment iterations
-		if cd.Spec.CanaryAnalysis.Iterations > cd.Status.Iterations {
+		if canary.Spec.CanaryAnalysis.Iterations > cd.Status.Iterations {
 			// If in "mirror" mode, mirror requests during the entire B/G canary test
 			if provider != "kubernetes" &&
-				cd.Spec.CanaryAnalysis.Mirror == true && mirrored == false {
+				canary.Spec.CanaryAnalysis.Mirror == true && mirrored == false {
 				if err := meshRouter.SetRoutes(cd, 100, 0, true); err != nil {
 					c.recordEventWarningf(cd, "%v", err)
 				}
@@ -401,7 +446,7 @@ func (c *Controller) advanceCanary(name string, namespace string, skipLivenessCh
 				return
 			}
 			c.recordEventInfof(cd, "Advance %s.%s canary iteration %v/%v",
-				cd.Name, cd.Namespace, cd.Status.Iterations+1, cd.Spec.CanaryAnalysis.Iterations)
+				cd.Name, cd.Namespace, cd.Status.Iterations+1, canary.Spec.CanaryAnalysis.Iterations)
 			return
 		}
 
@@ -411,9 +456,9 @@ func (c *Controller) advanceCanary(name string, namespace string, skipLivenessCh
 		}
 
 		// route all traffic to canary - max iterations reached
-		if cd.Spec.CanaryAnalysis.Iterations == cd.Status.Iterations {
+		if canary.Spec.CanaryAnalysis.Iterations == cd.Status.Iterations {
 			if provider != "kubernetes" {
-				if cd.Spec.CanaryAnalysis.Mirror {
+				if canary.Spec.CanaryAnalysis.Mirror {
 					c.recordEventInfof(cd, "Stop traffic mirroring and route all traffic to canary")
 				} else {
 					c.recordEventInfof(cd, "Routing all traffic to canary")
@@ -434,7 +479,7 @@ func (c *Controller) advanceCanary(name string, namespace string, skipLivenessCh
 		}
 
 		// promote canary - max iterations reached
-		if cd.Spec.CanaryAnalysis.Iterations < cd.Status.Iterations {
+		if canary.Spec.CanaryAnalysis.Iterations < cd.Status.Iterations {
 			c.recordEventInfof(cd, "Copying %s.%s template spec to %s.%s",
 				cd.Spec.TargetRef.Name, cd.Namespace, primaryName, cd.Namespace)
 			if err := c.deployer.Promote(cd); err != nil {
@@ -454,34 +499,34 @@ func (c *Controller) advanceCanary(name string, namespace string, skipLivenessCh
 	}
 
 	// strategy: Canary progressive traffic increase
-	if cd.Spec.CanaryAnalysis.StepWeight > 0 {
+	if canary.Spec.CanaryAnalysis.StepWeight > 0 || len(canary.Spec.CanaryAnalysis.StepWeights) > 0 {
+		// keep the current weight until the step checks or the step interval are satisfied
+		if canaryWeight > 0 && c.holdStep(cd) {
+			return
+		}
+
 		// increase traffic weight
 		if canaryWeight < maxWeight {
 			// If in "mirror" mode, do one step of mirroring before shifting traffic to canary.
 			// When mirroring, all requests go to primary and canary, but only responses from
 			// primary go back to the user.
-			if cd.Spec.CanaryAnalysis.Mirror && canaryWeight == 0 {
+			if canary.Spec.CanaryAnalysis.Mirror && canaryWeight == 0 {
 				if mirrored == false {
 					mirrored = true
 					primaryWeight = 100
 					canaryWeight = 0
 				} else {
 					mirrored = false
-					primaryWeight = 100 - cd.Spec.CanaryAnalysis.StepWeight
-					canaryWeight = cd.Spec.CanaryAnalysis.StepWeight
+					canaryWeight = canary.GetNextStepWeight(0)
+					primaryWeight = 100 - canaryWeight
 				}
 				c.logger.With("canary", fmt.Sprintf("%s.%s", name, namespace)).
 					Infof("Running mirror step %d/%d/%t", primaryWeight, canaryWeight, mirrored)
 			} else {
-
-				primaryWeight -= cd.Spec.CanaryAnalysis.StepWeight
-				if primaryWeight < 0 {
-					primaryWeight = 0
-				}
-				canaryWeight += cd.Spec.CanaryAnalysis.StepWeight
-				if canaryWeight > 100 {
-					canaryWeight = 100
-				}
+				// the next step is computed from the current weight so that the analysis
+				// resumes from the routing state after a restart
+				canaryWeight = canary.GetNextStepWeight(canaryWeight)
+				primaryWeight = 100 - canaryWeight
 			}
 
 			if err := meshRouter.SetRoutes(cd, primaryWeight, canaryWeight, mirrored); err != nil {
@@ -527,6 +572,43 @@ func (c *Controller) advanceCanary(name string, namespace string, skipLivenessCh
 
 }
 
+// holdStep returns true if the canary weight should not be advanced yet, the weight is advanced
+// after the step checks or after the step interval, whichever comes first, the successful checks
+// of the current step are counted in the status so that the dwell survives a restart
+func (c *Controller) holdStep(cd *flaggerv1.Canary) bool {
+	stepChecks := cd.Spec.CanaryAnalysis.StepChecks
+	stepInterval := cd.Spec.CanaryAnalysis.StepInterval
+	if stepChecks == 0 && stepInterval == "" {
+		return false
+	}
+
+	checks := cd.Status.StepChecks + 1
+	hold := stepChecks > 0 && checks < stepChecks
+	if stepInterval != "" {
+		dwell, err := time.ParseDuration(stepInterval)
+		if err != nil {
+			c.recordEventWarningf(cd, "Invalid step interval %s %v", stepInterval, err)
+		} else {
+			dwelling := time.Since(cd.Status.LastStepTime.Time) < dwell
+			if stepChecks > 0 {
+				hold = hold && dwelling
+			} else {
+				hold = dwelling
+			}
+		}
+	}
+
+	if hold {
+		if err := c.deployer.SetStatusStepChecks(cd, checks); err != nil {
+			c.recordEventWarningf(cd, "%v", err)
+			return true
+		}
+		c.logger.With("canary", fmt.Sprintf("%s.%s", cd.Name, cd.Namespace)).
+			Infof("Holding canary weight %v, step check %v passed", cd.Status.CanaryWeight, checks)
+	}
+	return hold
+}
+
 func (c *Controller) shouldSkipAnalysis(cd *flaggerv1.Canary, meshRouter router.Interface, primaryWeight int, canaryWeight int) bool {
 	if !cd.Spec.SkipAnalysis {
 		return false
@@ -636,6 +718,61 @@ func (c *Controller) checkCanaryStatus(cd *flaggerv1.Canary, shouldAdvance bool)
 	return false
 }
 
+// checkSuspended records the suspended condition and metric,
+// it returns false if the canary is suspended or has just been resumed and the advancement should stop
+func (c *Controller) checkSuspended(cd *flaggerv1.Canary) bool {
+	c.recorder.SetSuspended(cd, cd.Spec.Suspend)
+
+	suspended := false
+	for _, condition := range cd.Status.Conditions {
+		if condition.Type == flaggerv1.SuspendedType && condition.Status == corev1.ConditionTrue {
+			suspended = true
+		}
+	}
+
+	if cd.Spec.Suspend {
+		if !suspended {
+			c.recordEventInfof(cd, "Canary %s.%s suspended at weight %v iteration %v",
+				cd.Name, cd.Namespace, cd.Status.CanaryWeight, cd.Status.Iterations)
+			condition := flaggerv1.CanaryCondition{
+				Type:    flaggerv1.SuspendedType,
+				Status:  corev1.ConditionTrue,
+				Reason:  flaggerv1.SuspendedReason,
+				Message: "Canary advancement suspended.",
+			}
+			if err := c.deployer.SetStatusCondition(cd, condition); err != nil {
+				c.recordEventWarningf(cd, "%v", err)
+			}
+		}
+		return false
+	}
+
+	if suspended {
+		c.recordEventInfof(cd, "Canary %s.%s resumed at weight %v iteration %v",
+			cd.Name, cd.Namespace, cd.Status.CanaryWeight, cd.Status.Iterations)
+		condition := flaggerv1.CanaryCondition{
+			Type:    flaggerv1.SuspendedType,
+			Status:  corev1.ConditionFalse,
+			Reason:  flaggerv1.ResumedReason,
+			Message: "Canary advancement resumed.",
+		}
+		if err := c.deployer.SetStatusResumed(cd, condition); err != nil {
+			c.recordEventWarningf(cd, "%v", err)
+			return false
+		}
+
+		// the advancement continues from the recorded weight and iterations with the resumed status
+		canary, err := c.flaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).Get(cd.Name, metav1.GetOptions{})
+		if err != nil {
+			c.recordEventWarningf(cd, "%v", err)
+			return false
+		}
+		*cd = *canary
+	}
+
+	return true
+}
+
 func (c *Controller) hasCanaryRevisionChanged(cd *flaggerv1.Canary) bool {
 	if cd.Status.Phase == flaggerv1.CanaryPhaseProgressing {
 		if diff, _ := c.deployer.HasDeploymentChanged(cd); diff {
@@ -684,6 +821,15 @@ func (c *Controller) runConfirmPromotionHooks(canary *flaggerv1.Canary) bool {
 			if err != nil {
 				c.recordEventWarningf(canary, "Halt %s.%s advancement waiting for promotion approval %s",
 					canary.Name, canary.Namespace, webhook.Name)
+				condition := flaggerv1.CanaryCondition{
+					Type:    flaggerv1.PausedType,
+					Status:  corev1.ConditionTrue,
+					Reason:  flaggerv1.AwaitingPromotionApprovalReason,
+					Message: fmt.Sprintf("Waiting for promotion approval from %s.", webhook.Name),
+				}
+				if err := c.deployer.SetStatusCondition(canary, condition); err != nil {
+					c.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).Errorf("%v", err)
+				}
 				c.sendNotification(canary, "Canary promotion is waiting for approval.", false, false)
 				return false
 			} else {
@@ -694,20 +840,173 @@ func (c *Controller) runConfirmPromotionHooks(canary *flaggerv1.Canary) bool {
 	return true
 }
 
-func (c *Controller) runPreRolloutHooks(canary *flaggerv1.Canary) bool {
+// rollback routes all traffic to primary, scales the canary to zero,
+// marks the canary as failed with the rolled back condition and runs the post-rollout hooks
+func (c *Controller) rollback(cd *flaggerv1.Canary, meshRouter router.Interface, condition flaggerv1.CanaryCondition) bool {
+	// route all traffic back to primary
+	if err := meshRouter.SetRoutes(cd, 100, 0, false); err != nil {
+		c.recordEventWarningf(cd, "%v", err)
+		return false
+	}
+
+	c.recorder.SetWeight(cd, 100, 0)
+	c.recordEventWarningf(cd, "Canary failed! Scaling down %s.%s",
+		cd.Name, cd.Namespace)
+
+	// shutdown canary
+	if err := c.deployer.Scale(cd, 0); err != nil {
+		c.recordEventWarningf(cd, "%v", err)
+		return false
+	}
+
+	// mark canary as failed
+	status := flaggerv1.CanaryStatus{
+		Phase:        flaggerv1.CanaryPhaseFailed,
+		CanaryWeight: 0,
+		Conditions:   []flaggerv1.CanaryCondition{condition},
+	}
+	if err := c.deployer.SyncStatus(cd, status); err != nil {
+		c.logger.With("canary", fmt.Sprintf("%s.%s", cd.Name, cd.Namespace)).Errorf("%v", err)
+		return false
+	}
+
+	c.recorder.SetStatus(cd, flaggerv1.CanaryPhaseFailed)
+	c.runPostRolloutHooks(cd, flaggerv1.CanaryPhaseFailed)
+	return true
+}
+
+// promoteOnMaxDuration copies the canary spec to primary without running the promotion gates
+// and records the max duration reason on the progressing condition
+func (c *Controller) promoteOnMaxDuration(cd *flaggerv1.Canary, primaryName string) {
+	c.recordEventInfof(cd, "Copying %s.%s template spec to %s.%s",
+		cd.Spec.TargetRef.Name, cd.Namespace, primaryName, cd.Namespace)
+	if err := c.deployer.Promote(cd); err != nil {
+		c.recordEventWarningf(cd, "%v", err)
+		return
+	}
+
+	if err := c.deployer.SetStatusPhase(cd, flaggerv1.CanaryPhasePromoting); err != nil {
+		c.recordEventWarningf(cd, "%v", err)
+		return
+	}
+
+	canary, err := c.flaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).Get(cd.Name, metav1.GetOptions{})
+	if err != nil {
+		c.recordEventWarningf(cd, "%v", err)
+		return
+	}
+	condition := flaggerv1.CanaryCondition{
+		Type:   flaggerv1.ProgressingType,
+		Status: corev1.ConditionTrue,
+		Reason: flaggerv1.MaxDurationExceededReason,
+		Message: fmt.Sprintf("Canary analysis max duration %s exceeded, starting primary rolling update.",
+			cd.Spec.CanaryAnalysis.MaxDuration),
+	}
+	if err := c.deployer.SetStatusCondition(canary, condition); err != nil {
+		c.recordEventWarningf(cd, "%v", err)
+	}
+}
+
+// maxDurationExceeded returns true if the analysis of the current revision ran for more than the max duration,
+// the time spent suspended or held outside the deployment windows isn't counted
+func maxDurationExceeded(cd *flaggerv1.Canary, now time.Time) bool {
+	if cd.Spec.CanaryAnalysis.MaxDuration == "" {
+		return false
+	}
+	maxDuration, err := time.ParseDuration(cd.Spec.CanaryAnalysis.MaxDuration)
+	if err != nil {
+		return false
+	}
+
+	// the revision record is started when the canary enters the progressing phase
+	n := len(cd.Status.History)
+	if n == 0 || cd.Status.History[n-1].EndTime != nil {
+		return false
+	}
+	r := cd.Status.History[n-1]
+	elapsed := now.Sub(r.StartTime.Time)
+	if r.PausedDuration != nil {
+		elapsed -= r.PausedDuration.Duration
+	}
+
+	// the metric checks keep running while held outside the deployment windows
+	for _, condition := range cd.Status.Conditions {
+		if condition.Type == flaggerv1.PausedType && condition.Status == corev1.ConditionTrue &&
+			(condition.Reason == flaggerv1.OutsideDeploymentWindowReason || condition.Reason == flaggerv1.BlackoutPeriodReason) {
+			since := condition.LastTransitionTime.Time
+			if since.Before(r.StartTime.Time) {
+				since = r.StartTime.Time
+			}
+			elapsed -= now.Sub(since)
+		}
+	}
+
+	return elapsed > maxDuration
+}
+
+// clearAbort removes the abort annotation so that the next revision is analysed
+func (c *Controller) clearAbort(cd *flaggerv1.Canary) {
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		canary, err := c.flaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).Get(cd.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		cdCopy := canary.DeepCopy()
+		delete(cdCopy.Annotations, flaggerv1.AbortAnnotation)
+		_, err = c.flaggerClient.FlaggerV1alpha3().Canaries(cd.Namespace).Update(cdCopy)
+		return err
+	})
+	if err != nil {
+		c.logger.With("canary", fmt.Sprintf("%s.%s", cd.Name, cd.Namespace)).
+			Errorf("Removing the abort annotation failed %v", err)
+	}
+}
+
+// makeRolledBackCondition returns the rolled back condition with the reason of the canary failure,
+// the failed checks are attributed to the metric or webhook that last halted the advancement
+func makeRolledBackCondition(cd *flaggerv1.Canary, retriable bool, err error) flaggerv1.CanaryCondition {
+	condition := flaggerv1.CanaryCondition{
+		Type:   flaggerv1.RolledBackType,
+		Status: corev1.ConditionTrue,
+		Reason: flaggerv1.MetricCheckFailedReason,
+		Message: fmt.Sprintf("Canary analysis failed, failed checks threshold reached %v.",
+			cd.Status.FailedChecks),
+	}
+
+	if !retriable {
+		condition.Reason = flaggerv1.ProgressDeadlineExceededReason
+		condition.Message = fmt.Sprintf("Canary analysis failed, progress deadline exceeded %v.", err)
+		return condition
+	}
+
+	if n := len(cd.Status.History); n > 0 {
+		if r := cd.Status.History[n-1]; r.FailedWebhook != "" {
+			condition.Reason = flaggerv1.WebhookRejectedReason
+			condition.Message = fmt.Sprintf("Canary analysis failed, webhook %s rejected the rollout.", r.FailedWebhook)
+		} else if r.FailedMetric != "" {
+			condition.Message = fmt.Sprintf("Canary analysis failed, metric check %s failed.", r.FailedMetric)
+		}
+	}
+
+	return condition
+}
+
+// runPreRolloutHooks calls the pre-rollout webhooks and returns the name of the first failing one
+func (c *Controller) runPreRolloutHooks(canary *flaggerv1.Canary) (bool, string) {
 	for _, webhook := range canary.Spec.CanaryAnalysis.Webhooks {
 		if webhook.Type == flaggerv1.PreRolloutHook {
 			err := CallWebhook(canary.Name, canary.Namespace, flaggerv1.CanaryPhaseProgressing, webhook)
 			if err != nil {
 				c.recordEventWarningf(canary, "Halt %s.%s advancement pre-rollout check %s failed %v",
 					canary.Name, canary.Namespace, webhook.Name, err)
-				return false
+				return false, webhook.Name
 			} else {
 				c.recordEventInfof(canary, "Pre-rollout check %s passed", webhook.Name)
 			}
 		}
 	}
-	return true
+	return true, ""
 }
 
 func (c *Controller) runPostRolloutHooks(canary *flaggerv1.Canary, phase flaggerv1.CanaryPhase) bool {
@@ -725,7 +1024,9 @@ func (c *Controller) runPostRolloutHooks(canary *flaggerv1.Canary, phase flagger
 	return true
 }
 
-func (c *Controller) analyseCanary(r *flaggerv1.Canary) bool {
+// analyseCanary runs the rollout webhooks and the metric checks,
+// if a check fails it returns the name of the metric or webhook that halted the advancement
+func (c *Controller) analyseCanary(r *flaggerv1.Canary) (bool, string, string) {
 	// run external checks
 	for _, webhook := range r.Spec.CanaryAnalysis.Webhooks {
 		if webhook.Type == "" || webhook.Type == flaggerv1.RolloutHook {
@@ -733,7 +1034,7 @@ func (c *Controller) analyseCanary(r *flaggerv1.Canary) bool {
 			if err != nil {
 				c.recordEventWarningf(r, "Halt %s.%s advancement external check %s failed %v",
 					r.Name, r.Namespace, webhook.Name, err)
-				return false
+				return false, "", webhook.Name
 			}
 		}
 	}
@@ -754,25 +1055,63 @@ func (c *Controller) analyseCanary(r *flaggerv1.Canary) bool {
 
 	// override the global metrics server if one is specified in the canary spec
 	metricsServer := c.observerFactory.Client.GetMetricsServer()
-	if r.Spec.MetricsServer != "" {
-		metricsServer = r.Spec.MetricsServer
+	if r.Spec.MetricsServer != "" || r.Spec.MetricsProvider != nil {
 		var err error
-		observerFactory, err = metrics.NewFactory(metricsServer, metricsProvider, 5*time.Second)
+		observerFactory, err = c.canaryMetricsFactory(r, metricsProvider)
 		if err != nil {
-			c.recordEventErrorf(r, "Error building Prometheus client for %s %v", r.Spec.MetricsServer, err)
-			return false
+			c.recordEventErrorf(r, "Error building metrics client for %s.%s %v", r.Name, r.Namespace, err)
+			return false, "", ""
 		}
+		metricsServer = observerFactory.Client.GetMetricsServer()
 	}
 	observer := observerFactory.Observer(metricsProvider)
 
 	// run metrics checks
 	for _, metric := range r.Spec.CanaryAnalysis.Metrics {
+		// metric template checks
+		if metric.TemplateRef != nil {
+			query := func(name string) (float64, error) {
+				values, err := c.runMetricTemplate(r, metric, observerFactory.Client, name)
+				if err != nil {
+					return 0, err
+				}
+				if len(values) > 1 {
+					return 0, fmt.Errorf("%d series found, the comparison requires a single series", len(values))
+				}
+				return values[0], nil
+			}
+			values, err := c.runMetricTemplate(r, metric, observerFactory.Client, r.Spec.TargetRef.Name)
+			if err != nil {
+				if strings.Contains(err.Error(), "no values found") {
+					c.recordEventWarningf(r, "Halt advancement no values found for metric template %s",
+						metric.Name)
+				} else {
+					c.recordEventErrorf(r, "Metric template query failed for %s: %v", metric.Name, err)
+				}
+				return false, metric.Name, ""
+			}
+			if metric.Comparison != nil && len(values) > 1 {
+				c.recordEventErrorf(r, "Halt advancement metric %s returned %d series, the comparison requires a single series",
+					metric.Name, len(values))
+				return false, metric.Name, ""
+			}
+			for _, val := range values {
+				if ok := c.checkMetric(r, metric, val, "", query); !ok {
+					return false, metric.Name, ""
+				}
+			}
+			continue
+		}
+
 		if metric.Interval == "" {
 			metric.Interval = r.GetMetricInterval()
 		}
 
 		if metric.Name == "request-success-rate" {
-			val, err := observer.GetRequestSuccessRate(r.Spec.TargetRef.Name, r.Namespace, metric.Interval)
+			query := func(name string) (float64, error) {
+				return observer.GetRequestSuccessRate(c.metricTemplateModel(r, metric, name, metric.Interval, ""))
+			}
+			val, err := query(r.Spec.TargetRef.Name)
 			if err != nil {
 				if strings.Contains(err.Error(), "no values found") {
 					c.recordEventWarningf(r, "Halt advancement no values found for metric %s probably %s.%s is not receiving traffic",
@@ -780,19 +1119,20 @@ func (c *Controller) analyseCanary(r *flaggerv1.Canary) bool {
 				} else {
 					c.recordEventErrorf(r, "Metrics server %s query failed: %v", metricsServer, err)
 				}
-				return false
+				return false, metric.Name, ""
 			}
-			if float64(metric.Threshold) > val {
-				c.recordEventWarningf(r, "Halt %s.%s advancement success rate %.2f%% < %v%%",
-					r.Name, r.Namespace, val, metric.Threshold)
-				return false
+			if ok := c.checkMetric(r, metric, val, "%", query); !ok {
+				return false, metric.Name, ""
 			}
-
-			//c.recordEventInfof(r, "Check %s passed %.2f%% > %v%%", metric.Name, val, metric.Threshold)
 		}
 
 		if metric.Name == "request-duration" {
-			val, err := observer.GetRequestDuration(r.Spec.TargetRef.Name, r.Namespace, metric.Interval)
+			// the request duration is judged in milliseconds
+			query := func(name string) (float64, error) {
+				val, err := observer.GetRequestDuration(c.metricTemplateModel(r, metric, name, metric.Interval, ""))
+				return float64(val) / float64(time.Millisecond), err
+			}
+			val, err := query(r.Spec.TargetRef.Name)
 			if err != nil {
 				if strings.Contains(err.Error(), "no values found") {
 					c.recordEventWarningf(r, "Halt advancement no values found for metric %s probably %s.%s is not receiving traffic",
@@ -800,21 +1140,83 @@ func (c *Controller) analyseCanary(r *flaggerv1.Canary) bool {
 				} else {
 					c.recordEventErrorf(r, "Metrics server %s query failed: %v", metricsServer, err)
 				}
-				return false
+				return false, metric.Name, ""
 			}
-			t := time.Duration(metric.Threshold) * time.Millisecond
-			if val > t {
-				c.recordEventWarningf(r, "Halt %s.%s advancement request duration %v > %v",
-					r.Name, r.Namespace, val, t)
-				return false
+			if ok := c.checkMetric(r, metric, val, "ms", query); !ok {
+				return false, metric.Name, ""
+			}
+		}
+
+		if metric.Name == "request-rate" {
+			// the primary and the canary receive different shares of the traffic
+			if metric.Comparison != nil {
+				c.recordEventErrorf(r, "Halt advancement metric %s can't be compared with the primary", metric.Name)
+				return false, metric.Name, ""
+			}
+
+			query := func(name string) (float64, error) {
+				return observer.GetRequestRate(c.metricTemplateModel(r, metric, name, metric.Interval, ""))
+			}
+			val, err := query(r.Spec.TargetRef.Name)
+			if err != nil {
+				if strings.Contains(err.Error(), "no values found") {
+					c.recordEventWarningf(r, "Halt advancement no values found for metric %s probably %s.%s is not receiving traffic",
+						metric.Name, r.Spec.TargetRef.Name, r.Namespace)
+				} else {
+					c.recordEventErrorf(r, "Metrics server %s query failed: %v", metricsServer, err)
+				}
+				return false, metric.Name, ""
+			}
+			if ok := c.checkMetric(r, metric, val, "req/s", query); !ok {
+				return false, metric.Name, ""
+			}
+		}
+
+		if metric.Name == "error-budget-burn-rate" {
+			// the error budget is undefined without an objective under 100%
+			if metric.Objective == nil || *metric.Objective <= 0 || *metric.Objective >= 100 {
+				c.recordEventErrorf(r, "Halt advancement metric %s requires an objective between 0 and 100",
+					metric.Name)
+				return false, metric.Name, ""
 			}
 
-			//c.recordEventInfof(r, "Check %s passed %v < %v", metric.Name, val, metric.Threshold)
+			// the burn rate is the error rate divided by the error budget of the success rate objective
+			query := func(name string) (float64, error) {
+				val, err := observer.GetRequestSuccessRate(c.metricTemplateModel(r, metric, name, metric.Interval, ""))
+				if err != nil {
+					return 0, err
+				}
+				return (100 - val) / (100 - *metric.Objective), nil
+			}
+			val, err := query(r.Spec.TargetRef.Name)
+			if err != nil {
+				if strings.Contains(err.Error(), "no values found") {
+					c.recordEventWarningf(r, "Halt advancement no values found for metric %s probably %s.%s is not receiving traffic",
+						metric.Name, r.Spec.TargetRef.Name, r.Namespace)
+				} else {
+					c.recordEventErrorf(r, "Metrics server %s query failed: %v", metricsServer, err)
+				}
+				return false, metric.Name, ""
+			}
+			if ok := c.checkMetric(r, metric, val, "", query); !ok {
+				return false, metric.Name, ""
+			}
 		}
 
 		// custom checks
 		if metric.Query != "" {
-			val, err := observerFactory.Client.RunQuery(metric.Query)
+			client, err := metrics.ForInterval(observerFactory.Client, metric.Interval)
+			if err != nil {
+				c.recordEventErrorf(r, "Metrics server %s query failed for %s: %v", metricsServer, metric.Name, err)
+				return false, metric.Name, ""
+			}
+			model := c.metricTemplateModel(r, metric, r.Spec.TargetRef.Name, metric.Interval, metric.Query)
+			query, err := client.RenderQuery(model, metric.Query)
+			if err != nil {
+				c.recordEventErrorf(r, "Metric query render failed for %s: %v", metric.Name, err)
+				return false, metric.Name, ""
+			}
+			values, err := runMetricQuery(metric, client, query)
 			if err != nil {
 				if strings.Contains(err.Error(), "no values found") {
 					c.recordEventWarningf(r, "Halt advancement no values found for custom metric: %s",
@@ -822,15 +1224,321 @@ func (c *Controller) analyseCanary(r *flaggerv1.Canary) bool {
 				} else {
 					c.recordEventErrorf(r, "Metrics server %s query failed for %s: %v", metricsServer, metric.Name, err)
 				}
-				return false
+				return false, metric.Name, ""
 			}
-			if val > float64(metric.Threshold) {
-				c.recordEventWarningf(r, "Halt %s.%s advancement %s %.2f > %v",
-					r.Name, r.Namespace, metric.Name, val, metric.Threshold)
-				return false
+			for _, val := range values {
+				if ok := c.checkThresholdRange(r, metric, val, ""); !ok {
+					return false, metric.Name, ""
+				}
 			}
 		}
 	}
 
+	// compare the canary series with the primary ones
+	if r.Spec.CanaryAnalysis.Judge != nil {
+		if ok, metric := c.judgeCanary(r, observer, observerFactory.Client); !ok {
+			return false, metric, ""
+		}
+	}
+
+	return true, "", ""
+}
+
+// checkMetric judges the canary value against the primary value when a comparison is specified,
+// otherwise against the metric threshold range, query runs the metric for the given workload
+func (c *Controller) checkMetric(r *flaggerv1.Canary, metric flaggerv1.CanaryMetric, val float64, unit string,
+	query func(name string) (float64, error)) bool {
+	if metric.Comparison == nil {
+		return c.checkThresholdRange(r, metric, val, unit)
+	}
+
+	primaryName := fmt.Sprintf("%s-primary", r.Spec.TargetRef.Name)
+	primaryVal, err := query(primaryName)
+	if err != nil {
+		c.recordEventErrorf(r, "Halt advancement metric %s query failed for %s.%s: %v",
+			metric.Name, primaryName, r.Namespace, err)
+		return false
+	}
+
+	// a canary that performs better than the primary passes the check
+	delta := val - primaryVal
+	if metric.GetHigherIsBetter() {
+		delta = primaryVal - val
+	}
+	delta = math.Max(delta, 0)
+	deviation := 0.0
+	if delta > 0 {
+		deviation = math.Inf(1)
+		if primaryVal != 0 {
+			deviation = delta / math.Abs(primaryVal) * 100
+		}
+	}
+
+	var message string
+	if max := metric.Comparison.MaxDelta; max != nil && delta > *max {
+		message = fmt.Sprintf("%s canary %.2f%s primary %.2f%s delta %.2f%s > %v%s",
+			metric.Name, val, unit, primaryVal, unit, delta, unit, *max, unit)
+	} else if max := metric.Comparison.MaxDeviationPercent; max != nil && deviation > *max {
+		message = fmt.Sprintf("%s canary %.2f%s primary %.2f%s deviation %.2f%% > %v%%",
+			metric.Name, val, unit, primaryVal, unit, deviation, *max)
+	}
+	if message != "" {
+		c.recordEventWarningf(r, "Halt %s.%s advancement %s", r.Name, r.Namespace, message)
+		c.sendNotification(r, fmt.Sprintf("Halt advancement %s", message), false, true)
+		return false
+	}
+	return true
+}
+
+// checkThresholdRange records a warning event and returns false if the metric value is out of range
+func (c *Controller) checkThresholdRange(r *flaggerv1.Canary, metric flaggerv1.CanaryMetric, val float64, unit string) bool {
+	thresholdRange := metric.GetThresholdRange()
+	if thresholdRange.Min != nil && val < *thresholdRange.Min {
+		c.recordEventWarningf(r, "Halt %s.%s advancement %s %.2f%s < %v%s",
+			r.Name, r.Namespace, metric.Name, val, unit, *thresholdRange.Min, unit)
+		return false
+	}
+	if thresholdRange.Max != nil && val > *thresholdRange.Max {
+		c.recordEventWarningf(r, "Halt %s.%s advancement %s %.2f%s > %v%s",
+			r.Name, r.Namespace, metric.Name, val, unit, *thresholdRange.Max, unit)
+		return false
+	}
 	return true
 }
+
+// runMetricTemplate renders the query of the MetricTemplate referenced by the metric for the named workload
+// and runs it on the template metrics server, or on the canary one if the template doesn't specify an address
+func (c *Controller) runMetricTemplate(r *flaggerv1.Canary, metric flaggerv1.CanaryMetric, client metrics.Client, name string) ([]float64, error) {
+	client, query, err := c.renderMetricTemplate(r, metric, client, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return runMetricQuery(metric, client, query)
+}
+
+// runMetricQuery executes the query of a custom or template metric and returns the values checked against
+// the threshold, one per series unless the metric aggregation reduces the series to a single value
+func runMetricQuery(metric flaggerv1.CanaryMetric, client metrics.Client, query string) ([]float64, error) {
+	aggregation := metric.Aggregation
+	if aggregation == nil {
+		aggregation = &flaggerv1.CanaryMetricAggregation{}
+	}
+	percentile := 0.0
+	if aggregation.Percentile != nil {
+		percentile = *aggregation.Percentile
+	}
+
+	var values []float64
+	if aggregation.Window == "" {
+		var err error
+		values, err = metrics.QuerySeries(client, query)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		window, err := time.ParseDuration(aggregation.Window)
+		if err != nil {
+			return nil, fmt.Errorf("aggregation window %s parse error %v", aggregation.Window, err)
+		}
+		step := window / 10
+		if aggregation.Step != "" {
+			step, err = time.ParseDuration(aggregation.Step)
+			if err != nil {
+				return nil, fmt.Errorf("aggregation step %s parse error %v", aggregation.Step, err)
+			}
+		}
+
+		end := time.Now()
+		series, err := metrics.QueryRangeSeries(client, query, end.Add(-window), end, step)
+		if err != nil {
+			return nil, err
+		}
+		for _, samples := range series {
+			val, err := metrics.Aggregate(samples, aggregation.Function, percentile)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, val)
+		}
+	}
+
+	if aggregation.Series == flaggerv1.CanaryMetricSeriesAggregate && len(values) > 1 {
+		val, err := metrics.Aggregate(values, aggregation.Function, percentile)
+		if err != nil {
+			return nil, err
+		}
+		values = []float64{val}
+	}
+
+	return values, nil
+}
+
+// renderMetricTemplate renders the query of the MetricTemplate referenced by the metric for the named workload
+// and returns it along with the client of the metrics server the query should run on
+func (c *Controller) renderMetricTemplate(r *flaggerv1.Canary, metric flaggerv1.CanaryMetric,
+	client metrics.Client, name string) (metrics.Client, string, error) {
+	namespace := metric.TemplateRef.Namespace
+	if namespace == "" {
+		namespace = r.Namespace
+	}
+
+	template, err := c.flaggerClient.FlaggerV1beta1().MetricTemplates(namespace).Get(metric.TemplateRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, "", fmt.Errorf("metric template %s.%s query error %v", metric.TemplateRef.Name, namespace, err)
+	}
+
+	provider := template.Spec.Provider
+	if !metrics.IsSupportedProvider(provider.Type) {
+		return nil, "", fmt.Errorf("metric template %s.%s provider %s not supported", template.Name, template.Namespace, provider.Type)
+	}
+
+	// the template runs on its own metrics server when it specifies an address or another provider than Prometheus
+	if provider.Address != "" || (provider.Type != "" && provider.Type != metrics.PrometheusProvider) {
+		key := fmt.Sprintf("template/%s.%s", template.Name, template.Namespace)
+		options := metrics.ClientOptions{Method: provider.Method, JSONPath: provider.JSONPath}
+		client, err = c.metricsClient(key, provider.Type, provider.Address, namespace, provider.SecretRef, options)
+		if err != nil {
+			return nil, "", fmt.Errorf("metric template %s.%s %v", template.Name, template.Namespace, err)
+		}
+	}
+
+	interval := metric.Interval
+	if interval == "" {
+		interval = template.Spec.Interval
+	}
+	if interval == "" {
+		interval = r.GetMetricInterval()
+	}
+
+	client, err = metrics.ForInterval(client, interval)
+	if err != nil {
+		return nil, "", fmt.Errorf("metric template %s.%s %v", template.Name, template.Namespace, err)
+	}
+
+	query, err := client.RenderQuery(c.metricTemplateModel(r, metric, name, interval, template.Spec.Query), template.Spec.Query)
+	if err != nil {
+		return nil, "", fmt.Errorf("metric template %s.%s render error %v", template.Name, template.Namespace, err)
+	}
+
+	return client, query, nil
+}
+
+// metricTemplateModel returns the canary variables of the query that runs for the named workload,
+// the ReplicaSet lookup of the pod template hash only runs when the query references it
+func (c *Controller) metricTemplateModel(r *flaggerv1.Canary, metric flaggerv1.CanaryMetric,
+	name string, interval string, query string) metrics.MetricTemplateModel {
+	model := metrics.MetricTemplateModel{
+		Name:      name,
+		Namespace: r.Namespace,
+		Interval:  interval,
+		Canary:    r.Name,
+		Target:    name,
+		Primary:   fmt.Sprintf("%s-primary", r.Spec.TargetRef.Name),
+		Port:      r.Spec.Service.Port,
+		Weight:    r.Status.CanaryWeight,
+		Variables: metric.Variables,
+	}
+	if r.Spec.IngressRef != nil {
+		model.Ingress = r.Spec.IngressRef.Name
+	}
+	if metric.Percentile != nil {
+		model.Percentile = *metric.Percentile
+	}
+	if strings.Contains(query, "PodTemplateHash") {
+		model.PodTemplateHash = c.podTemplateHash(r, name)
+	}
+	return model
+}
+
+// podTemplateHash returns the pod-template-hash label of the current ReplicaSet of the named deployment,
+// the hash is empty for the other kinds of workloads or when the ReplicaSet can't be found
+func (c *Controller) podTemplateHash(r *flaggerv1.Canary, name string) string {
+	switch r.Spec.TargetRef.Kind {
+	case "DaemonSet", "StatefulSet":
+		return ""
+	}
+
+	dep, err := c.kubeClient.AppsV1().Deployments(r.Namespace).Get(name, metav1.GetOptions{})
+	if err != nil || dep.Spec.Selector == nil {
+		return ""
+	}
+	selector, err := metav1.LabelSelectorAsSelector(dep.Spec.Selector)
+	if err != nil {
+		return ""
+	}
+	list, err := c.kubeClient.AppsV1().ReplicaSets(r.Namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return ""
+	}
+
+	revision := dep.Annotations["deployment.kubernetes.io/revision"]
+	for _, rs := range list.Items {
+		if metav1.IsControlledBy(&rs, dep) && rs.Annotations["deployment.kubernetes.io/revision"] == revision {
+			return rs.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+		}
+	}
+	return ""
+}
+
+// canaryMetricsFactory builds the metrics factory of the canary metrics server and provider,
+// the Prometheus provider defaults to the global metrics server
+func (c *Controller) canaryMetricsFactory(r *flaggerv1.Canary, meshProvider string) (*metrics.Factory, error) {
+	provider, address := metrics.PrometheusProvider, r.Spec.MetricsServer
+	var secretRef *corev1.LocalObjectReference
+	if p := r.Spec.MetricsProvider; p != nil {
+		if p.Type != "" {
+			provider = p.Type
+		}
+		secretRef = p.SecretRef
+	}
+
+	if address == "" && provider == metrics.PrometheusProvider {
+		// reuse the global client and its credentials when the canary doesn't set its own
+		if secretRef == nil {
+			return &metrics.Factory{MeshProvider: meshProvider, Client: c.observerFactory.Client}, nil
+		}
+		address = c.observerFactory.Client.GetMetricsServer()
+	}
+
+	key := fmt.Sprintf("canary/%s.%s", r.Name, r.Namespace)
+	client, err := c.metricsClient(key, provider, address, r.Namespace, secretRef, metrics.ClientOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &metrics.Factory{MeshProvider: meshProvider, Client: client}, nil
+}
+
+// cachedMetricsClient is a metrics client along with the provider settings it was built from
+type cachedMetricsClient struct {
+	settings string
+	client   metrics.Client
+}
+
+// metricsClient returns the cached client of the canary or template key, the client is rebuilt
+// when the provider settings or the resource version of the credentials Secret have changed
+func (c *Controller) metricsClient(key string, provider string, address string, namespace string,
+	secretRef *corev1.LocalObjectReference, options metrics.ClientOptions) (metrics.Client, error) {
+	var credentials map[string][]byte
+	var secretName, secretVersion string
+	if secretRef != nil {
+		secret, err := c.kubeClient.CoreV1().Secrets(namespace).Get(secretRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("metrics provider secret %s.%s query error %v", secretRef.Name, namespace, err)
+		}
+		credentials, secretName, secretVersion = secret.Data, secret.Name, secret.ResourceVersion
+	}
+
+	settings := fmt.Sprintf("%s|%s|%s|%s|%s.%s|%s", provider, address, options.Method, options.JSONPath,
+		secretName, namespace, secretVersion)
+	if cached, ok := c.metricsClients.Load(key); ok && cached.(cachedMetricsClient).settings == settings {
+		return cached.(cachedMetricsClient).client, nil
+	}
+
+	client, err := metrics.NewClient(provider, address, credentials, options, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("error building metrics client for %s %v", address, err)
+	}
+	c.metricsClients.Store(key, cachedMetricsClient{settings: settings, client: client})
+	return client, nil
+}
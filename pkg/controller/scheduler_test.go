@@ -2,12 +2,22 @@ package controller
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 
 	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1alpha3"
+	flaggerv1beta1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	"github.com/weaveworks/flagger/pkg/metrics"
 )
 
 func TestScheduler_Init(t *testing.T) {
@@ -66,6 +76,174 @@ func TestScheduler_Rollback(t *testing.T) {
 	if c.Status.Phase != flaggerv1.CanaryPhaseFailed {
 		t.Errorf("Got canary state %v wanted %v", c.Status.Phase, flaggerv1.CanaryPhaseFailed)
 	}
+
+	if len(c.Status.History) != 1 {
+		t.Fatalf("Got history length %v wanted %v", len(c.Status.History), 1)
+	}
+
+	if c.Status.History[0].Outcome != flaggerv1.CanaryRevisionFailed {
+		t.Errorf("Got history outcome %v wanted %v", c.Status.History[0].Outcome, flaggerv1.CanaryRevisionFailed)
+	}
+
+	if c.Status.History[0].FailedChecks != 11 {
+		t.Errorf("Got history failed checks %v wanted %v", c.Status.History[0].FailedChecks, 11)
+	}
+
+	for _, condition := range c.Status.Conditions {
+		if condition.Type == flaggerv1.RolledBackType && condition.Reason != flaggerv1.MetricCheckFailedReason {
+			t.Errorf("Got rolled back reason %v wanted %v", condition.Reason, flaggerv1.MetricCheckFailedReason)
+		}
+	}
+}
+
+func TestScheduler_ManualRollback(t *testing.T) {
+	mocks := SetupMocks(nil)
+	// init
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	// update
+	dep2 := newTestDeploymentV2()
+	_, err := mocks.kubeClient.AppsV1().Deployments("default").Update(dep2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// detect changes
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+	// advance
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	// abort
+	c, err := mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	c.Annotations = map[string]string{flaggerv1.AbortAnnotation: "true"}
+	_, err = mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Update(c)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	c, err = mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if c.Status.Phase != flaggerv1.CanaryPhaseFailed {
+		t.Errorf("Got canary state %v wanted %v", c.Status.Phase, flaggerv1.CanaryPhaseFailed)
+	}
+
+	if c.IsAborted() {
+		t.Errorf("Got abort annotation %v wanted it removed", c.Annotations)
+	}
+
+	for _, condition := range c.Status.Conditions {
+		if condition.Type == flaggerv1.RolledBackType && condition.Reason != flaggerv1.ManualRollbackReason {
+			t.Errorf("Got rolled back reason %v wanted %v", condition.Reason, flaggerv1.ManualRollbackReason)
+		}
+	}
+
+	primaryWeight, canaryWeight, _, err := mocks.router.GetRoutes(c)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if primaryWeight != 100 || canaryWeight != 0 {
+		t.Errorf("Got primary weight %v canary weight %v wanted 100 and 0", primaryWeight, canaryWeight)
+	}
+
+	d, err := mocks.kubeClient.AppsV1().Deployments("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if *d.Spec.Replicas != 0 {
+		t.Errorf("Got canary replicas %v wanted %v", *d.Spec.Replicas, 0)
+	}
+}
+
+func TestScheduler_Suspend(t *testing.T) {
+	mocks := SetupMocks(nil)
+	// init
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	// update
+	dep2 := newTestDeploymentV2()
+	_, err := mocks.kubeClient.AppsV1().Deployments("default").Update(dep2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// detect changes
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+	// advance
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	setSuspend := func(suspend bool) {
+		c, err := mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		c.Spec.Suspend = suspend
+		_, err = mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Update(c)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	getCanary := func() *flaggerv1.Canary {
+		c, err := mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		return c
+	}
+
+	getSuspended := func(c *flaggerv1.Canary) *flaggerv1.CanaryCondition {
+		for _, condition := range c.Status.Conditions {
+			if condition.Type == flaggerv1.SuspendedType {
+				return &condition
+			}
+		}
+		return nil
+	}
+
+	weight := getCanary().Status.CanaryWeight
+	if weight != 10 {
+		t.Fatalf("Got canary weight %v wanted %v", weight, 10)
+	}
+
+	setSuspend(true)
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	c := getCanary()
+	if c.Status.CanaryWeight != weight {
+		t.Errorf("Got canary weight %v wanted %v", c.Status.CanaryWeight, weight)
+	}
+
+	if cond := getSuspended(c); cond == nil || cond.Status != "True" {
+		t.Errorf("Got suspended condition %v wanted status True", cond)
+	}
+
+	// resume and advance on the same run
+	setSuspend(false)
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	c = getCanary()
+	if c.Status.CanaryWeight != weight+10 {
+		t.Errorf("Got canary weight %v wanted %v", c.Status.CanaryWeight, weight+10)
+	}
+
+	if cond := getSuspended(c); cond == nil || cond.Status != "False" || cond.Reason != flaggerv1.ResumedReason {
+		t.Errorf("Got suspended condition %v wanted status False", cond)
+	}
+
+	if n := len(c.Status.History); n == 0 || c.Status.History[n-1].ResumeTime == nil || c.Status.History[n-1].PausedDuration == nil {
+		t.Errorf("Got history %v wanted the resume time and the paused duration", c.Status.History)
+	}
 }
 
 func TestScheduler_SkipAnalysis(t *testing.T) {
@@ -320,6 +498,12 @@ func TestScheduler_Promotion(t *testing.T) {
 	if c.Status.Phase != flaggerv1.CanaryPhaseSucceeded {
 		t.Errorf("Got canary state %v wanted %v", c.Status.Phase, flaggerv1.CanaryPhaseSucceeded)
 	}
+
+	last := c.Status.History[len(c.Status.History)-1]
+	if last.Outcome != flaggerv1.CanaryRevisionSucceeded || last.Revision != c.Status.LastPromotedSpec {
+		t.Errorf("Got history outcome %v for %v wanted %v for %v",
+			last.Outcome, last.Revision, flaggerv1.CanaryRevisionSucceeded, c.Status.LastPromotedSpec)
+	}
 }
 
 func TestScheduler_Mirroring(t *testing.T) {
@@ -380,6 +564,226 @@ func TestScheduler_Mirroring(t *testing.T) {
 	}
 }
 
+func TestScheduler_StepWeights(t *testing.T) {
+	mocks := SetupMocks(newTestCanaryStepWeights())
+	// init
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	// update
+	dep2 := newTestDeploymentV2()
+	_, err := mocks.kubeClient.AppsV1().Deployments("default").Update(dep2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// detect pod spec changes
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	for _, step := range []int{1, 5} {
+		// advance
+		mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+		primaryWeight, canaryWeight, _, err := mocks.router.GetRoutes(mocks.canary)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if canaryWeight != step {
+			t.Errorf("Got canary route %v wanted %v", canaryWeight, step)
+		}
+
+		if primaryWeight != 100-step {
+			t.Errorf("Got primary route %v wanted %v", primaryWeight, 100-step)
+		}
+	}
+
+	// resume from a weight that was set before a restart
+	err = mocks.router.SetRoutes(mocks.canary, 75, 25, false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// advance
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	primaryWeight, canaryWeight, _, err := mocks.router.GetRoutes(mocks.canary)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if canaryWeight != 50 {
+		t.Errorf("Got canary route %v wanted %v", canaryWeight, 50)
+	}
+
+	if primaryWeight != 50 {
+		t.Errorf("Got primary route %v wanted %v", primaryWeight, 50)
+	}
+
+	// promote
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	c, err := mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if c.Status.Phase != flaggerv1.CanaryPhasePromoting {
+		t.Errorf("Got canary state %v wanted %v", c.Status.Phase, flaggerv1.CanaryPhasePromoting)
+	}
+}
+
+func TestScheduler_StepChecks(t *testing.T) {
+	canary := newTestCanary()
+	canary.Spec.CanaryAnalysis.StepChecks = 2
+	mocks := SetupMocks(canary)
+	// init
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	// update
+	dep2 := newTestDeploymentV2()
+	_, err := mocks.kubeClient.AppsV1().Deployments("default").Update(dep2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// detect pod spec changes
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	// advance to the first step
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	// hold the first step
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	_, canaryWeight, _, err := mocks.router.GetRoutes(mocks.canary)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if canaryWeight != 10 {
+		t.Errorf("Got canary route %v wanted %v", canaryWeight, 10)
+	}
+
+	c, err := mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if c.Status.StepChecks != 1 {
+		t.Errorf("Got step checks %v wanted %v", c.Status.StepChecks, 1)
+	}
+
+	// advance after the second successful check
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	_, canaryWeight, _, err = mocks.router.GetRoutes(mocks.canary)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if canaryWeight != 20 {
+		t.Errorf("Got canary route %v wanted %v", canaryWeight, 20)
+	}
+
+	c, err = mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if c.Status.StepChecks != 0 {
+		t.Errorf("Got step checks %v wanted %v", c.Status.StepChecks, 0)
+	}
+}
+
+func TestScheduler_StepInterval(t *testing.T) {
+	canary := newTestCanary()
+	canary.Spec.CanaryAnalysis.StepInterval = "1h"
+	mocks := SetupMocks(canary)
+	// init
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	// update
+	dep2 := newTestDeploymentV2()
+	_, err := mocks.kubeClient.AppsV1().Deployments("default").Update(dep2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// detect pod spec changes
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	// advance to the first step
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	// hold the first step
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	_, canaryWeight, _, err := mocks.router.GetRoutes(mocks.canary)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if canaryWeight != 10 {
+		t.Errorf("Got canary route %v wanted %v", canaryWeight, 10)
+	}
+
+	// the step started before a restart
+	c, err := mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	c.Status.LastStepTime = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	_, err = mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").UpdateStatus(c)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// advance after the step interval
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	_, canaryWeight, _, err = mocks.router.GetRoutes(mocks.canary)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if canaryWeight != 20 {
+		t.Errorf("Got canary route %v wanted %v", canaryWeight, 20)
+	}
+}
+
+func TestScheduler_StepChecksOrInterval(t *testing.T) {
+	canary := newTestCanary()
+	canary.Spec.CanaryAnalysis.StepChecks = 3
+	canary.Spec.CanaryAnalysis.StepInterval = "1h"
+	mocks := SetupMocks(canary)
+
+	tests := []struct {
+		checks   int
+		lastStep time.Duration
+		hold     bool
+	}{
+		{checks: 0, lastStep: time.Minute, hold: true},
+		// the step checks passed before the step interval
+		{checks: 2, lastStep: time.Minute, hold: false},
+		// the step interval elapsed before the step checks
+		{checks: 0, lastStep: 2 * time.Hour, hold: false},
+	}
+
+	for i, test := range tests {
+		cd, err := mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		cd.Status.StepChecks = test.checks
+		cd.Status.LastStepTime = metav1.NewTime(time.Now().Add(-test.lastStep))
+
+		if hold := mocks.ctrl.holdStep(cd); hold != test.hold {
+			t.Errorf("Got hold %v wanted %v for step %d", hold, test.hold, i)
+		}
+	}
+}
+
 func TestScheduler_ABTesting(t *testing.T) {
 	mocks := SetupMocks(newTestCanaryAB())
 	// init
@@ -601,3 +1005,796 @@ func TestScheduler_TargetPortName(t *testing.T) {
 
 	}
 }
+
+func TestScheduler_RolledBackCondition(t *testing.T) {
+	cd := newTestCanary()
+	cd.Status.History = []flaggerv1.CanaryRevision{{Revision: "1", FailedWebhook: "load-test"}}
+
+	condition := makeRolledBackCondition(cd, true, nil)
+	if condition.Reason != flaggerv1.WebhookRejectedReason {
+		t.Errorf("Got reason %v wanted %v", condition.Reason, flaggerv1.WebhookRejectedReason)
+	}
+
+	condition = makeRolledBackCondition(cd, false, fmt.Errorf("deadline"))
+	if condition.Reason != flaggerv1.ProgressDeadlineExceededReason {
+		t.Errorf("Got reason %v wanted %v", condition.Reason, flaggerv1.ProgressDeadlineExceededReason)
+	}
+}
+
+func TestScheduler_MaxDuration(t *testing.T) {
+	tests := map[flaggerv1.MaxDurationAction]struct {
+		phase         flaggerv1.CanaryPhase
+		conditionType flaggerv1.CanaryConditionType
+	}{
+		flaggerv1.MaxDurationRollback: {phase: flaggerv1.CanaryPhaseFailed, conditionType: flaggerv1.RolledBackType},
+		flaggerv1.MaxDurationPromote:  {phase: flaggerv1.CanaryPhasePromoting, conditionType: flaggerv1.ProgressingType},
+	}
+
+	for action, want := range tests {
+		canary := newTestCanary()
+		canary.Spec.CanaryAnalysis.MaxDuration = "1h"
+		canary.Spec.CanaryAnalysis.MaxDurationAction = action
+		mocks := SetupMocks(canary)
+		// init
+		mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+		// update
+		dep2 := newTestDeploymentV2()
+		_, err := mocks.kubeClient.AppsV1().Deployments("default").Update(dep2)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		// detect pod spec changes
+		mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+		// advance
+		mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+		// start the analysis two hours ago
+		c, err := mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		c.Status.History[len(c.Status.History)-1].StartTime = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+		_, err = mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").UpdateStatus(c)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		// end the analysis
+		mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+		c, err = mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if c.Status.Phase != want.phase {
+			t.Errorf("Got canary state %v wanted %v for %s", c.Status.Phase, want.phase, action)
+		}
+
+		var reason string
+		for _, condition := range c.Status.Conditions {
+			if condition.Type == want.conditionType {
+				reason = condition.Reason
+			}
+		}
+		if reason != flaggerv1.MaxDurationExceededReason {
+			t.Errorf("Got %s reason %v wanted %v for %s", want.conditionType, reason, flaggerv1.MaxDurationExceededReason, action)
+		}
+	}
+}
+
+func TestScheduler_MaxDurationPaused(t *testing.T) {
+	now := time.Now()
+	cd := newTestCanary()
+	cd.Spec.CanaryAnalysis.MaxDuration = "1h"
+	cd.Status.History = []flaggerv1.CanaryRevision{
+		{Revision: "1", StartTime: metav1.NewTime(now.Add(-2 * time.Hour))},
+	}
+
+	if !maxDurationExceeded(cd, now) {
+		t.Errorf("Got max duration not exceeded wanted exceeded after 2h")
+	}
+
+	// suspended for 90 minutes
+	cd.Status.History[0].PausedDuration = &metav1.Duration{Duration: 90 * time.Minute}
+	if maxDurationExceeded(cd, now) {
+		t.Errorf("Got max duration exceeded wanted the suspension left out")
+	}
+
+	// held by a blackout period for the last 90 minutes
+	cd.Status.History[0].PausedDuration = nil
+	cd.Status.Conditions = []flaggerv1.CanaryCondition{
+		{
+			Type:               flaggerv1.PausedType,
+			Status:             corev1.ConditionTrue,
+			Reason:             flaggerv1.BlackoutPeriodReason,
+			LastTransitionTime: metav1.NewTime(now.Add(-90 * time.Minute)),
+		},
+	}
+	if maxDurationExceeded(cd, now) {
+		t.Errorf("Got max duration exceeded wanted the blackout period left out")
+	}
+}
+
+func TestScheduler_MetricTemplate(t *testing.T) {
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("query")
+		json := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1545905245.458,"5"]}]}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	mocks := SetupMocks(nil)
+	template := &flaggerv1beta1.MetricTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "error-rate", Namespace: "flagger"},
+		Spec: flaggerv1beta1.MetricTemplateSpec{
+			Provider: flaggerv1beta1.MetricTemplateProvider{Type: "prometheus", Address: ts.URL},
+			Query:    `sum(rate(http_errors{namespace="{{ .Namespace }}",pod=~"{{ .Target }}-[0-9a-z]+"}[{{ .Interval }}]))`,
+			Interval: "2m",
+		},
+	}
+	_, err := mocks.flaggerClient.FlaggerV1beta1().MetricTemplates("flagger").Create(template)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cd := newTestCanary()
+	cd.Spec.CanaryAnalysis.Metrics = []flaggerv1.CanaryMetric{
+		{
+			Name:        "error-rate",
+			Threshold:   10,
+			TemplateRef: &flaggerv1.MetricTemplateRef{Name: "error-rate", Namespace: "flagger"},
+		},
+	}
+
+	ok, metric, _ := mocks.ctrl.analyseCanary(cd)
+	if !ok {
+		t.Errorf("Got halted by %s wanted ok", metric)
+	}
+
+	expected := `sum(rate(http_errors{namespace="default",pod=~"podinfo-[0-9a-z]+"}[2m]))`
+	if query != expected {
+		t.Errorf("Got query %s wanted %s", query, expected)
+	}
+
+	// the canary metric interval and threshold take precedence
+	cd.Spec.CanaryAnalysis.Metrics[0].Interval = "30s"
+	cd.Spec.CanaryAnalysis.Metrics[0].Threshold = 1
+
+	ok, metric, _ = mocks.ctrl.analyseCanary(cd)
+	if ok || metric != "error-rate" {
+		t.Errorf("Got ok %v metric %s wanted halted by error-rate", ok, metric)
+	}
+
+	if !strings.Contains(query, "[30s]") {
+		t.Errorf("Got query %s wanted interval 30s", query)
+	}
+
+	// missing template
+	cd.Spec.CanaryAnalysis.Metrics[0].TemplateRef.Namespace = ""
+	if ok, _, _ := mocks.ctrl.analyseCanary(cd); ok {
+		t.Errorf("Got ok wanted halted by missing template")
+	}
+}
+
+func TestScheduler_Datadog(t *testing.T) {
+	var queries []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("DD-API-KEY") != "api-key" || r.Header.Get("DD-APPLICATION-KEY") != "app-key" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["Forbidden"]}`))
+			return
+		}
+		queries = append(queries, r.URL.Query().Get("query"))
+		json := `{"status":"ok","series":[{"pointlist":[[1545905245000,99.5]]}]}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	mocks := SetupMocks(nil)
+	for _, namespace := range []string{"default", "flagger"} {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "datadog", Namespace: namespace},
+			Data: map[string][]byte{
+				metrics.DatadogAPIKeySecretKey:         []byte("api-key"),
+				metrics.DatadogApplicationKeySecretKey: []byte("app-key"),
+			},
+		}
+		if _, err := mocks.kubeClient.CoreV1().Secrets(namespace).Create(secret); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	template := &flaggerv1beta1.MetricTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "latency", Namespace: "flagger"},
+		Spec: flaggerv1beta1.MetricTemplateSpec{
+			Provider: flaggerv1beta1.MetricTemplateProvider{
+				Type:      "datadog",
+				Address:   ts.URL,
+				SecretRef: &corev1.LocalObjectReference{Name: "datadog"},
+			},
+			Query: `avg:http.latency{kube_deployment:{{ .Target }}}`,
+		},
+	}
+	if _, err := mocks.flaggerClient.FlaggerV1beta1().MetricTemplates("flagger").Create(template); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cd := newTestCanary()
+	cd.Spec.MetricsServer = ts.URL
+	cd.Spec.MetricsProvider = &flaggerv1.CanaryMetricsProvider{
+		Type:      "datadog",
+		SecretRef: &corev1.LocalObjectReference{Name: "datadog"},
+	}
+	cd.Spec.CanaryAnalysis.Metrics = []flaggerv1.CanaryMetric{
+		{Name: "request-success-rate", Threshold: 99, Interval: "1m"},
+		{Name: "hits", Query: `sum:trace.http.request.hits{kube_deployment:podinfo}`, Threshold: 100},
+		{Name: "latency", Threshold: 100, TemplateRef: &flaggerv1.MetricTemplateRef{Name: "latency", Namespace: "flagger"}},
+	}
+
+	ok, metric, _ := mocks.ctrl.analyseCanary(cd)
+	if !ok {
+		t.Errorf("Got halted by %s wanted ok", metric)
+	}
+	if len(queries) != 3 || queries[2] != `avg:http.latency{kube_deployment:podinfo}` {
+		t.Errorf("Got queries %v wanted 3 with the latency template last", queries)
+	}
+
+	// wrong credentials
+	secret, _ := mocks.kubeClient.CoreV1().Secrets("default").Get("datadog", metav1.GetOptions{})
+	secret.Data[metrics.DatadogAPIKeySecretKey] = []byte("invalid")
+	// the fake clientset doesn't bump the resource version like the API server
+	secret.ResourceVersion = "2"
+	if _, err := mocks.kubeClient.CoreV1().Secrets("default").Update(secret); err != nil {
+		t.Fatal(err.Error())
+	}
+	if ok, _, _ := mocks.ctrl.analyseCanary(cd); ok {
+		t.Errorf("Got ok wanted halted by the Datadog API error")
+	}
+}
+
+func TestScheduler_PrometheusCredentials(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1545905245.458,"99.5"]}]}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	mocks := SetupMocks(nil)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "prometheus", Namespace: "default"},
+		Data:       map[string][]byte{metrics.PrometheusTokenSecretKey: []byte("token")},
+	}
+	if _, err := mocks.kubeClient.CoreV1().Secrets("default").Create(secret); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cd := newTestCanary()
+	cd.Spec.MetricsServer = ts.URL
+	cd.Spec.CanaryAnalysis.Metrics = []flaggerv1.CanaryMetric{
+		{Name: "request-success-rate", Threshold: 99, Interval: "1m"},
+	}
+
+	if ok, _, _ := mocks.ctrl.analyseCanary(cd); ok {
+		t.Errorf("Got ok wanted halted by the unauthorized error")
+	}
+
+	cd.Spec.MetricsProvider = &flaggerv1.CanaryMetricsProvider{
+		Type:      "prometheus",
+		SecretRef: &corev1.LocalObjectReference{Name: "prometheus"},
+	}
+	if ok, metric, _ := mocks.ctrl.analyseCanary(cd); !ok {
+		t.Errorf("Got halted by %s wanted ok", metric)
+	}
+}
+
+func TestScheduler_InfluxDB(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json := `{"results":[{"statement_id":0,"series":[{"name":"errors","columns":["time","sum"],"values":[["2020-01-10T10:00:00Z",3]]}]}]}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	mocks := SetupMocks(nil)
+	cd := newTestCanary()
+	cd.Spec.MetricsServer = ts.URL + "?db=telegraf"
+	cd.Spec.MetricsProvider = &flaggerv1.CanaryMetricsProvider{Type: "influxdb"}
+	cd.Spec.CanaryAnalysis.Metrics = []flaggerv1.CanaryMetric{
+		{Name: "errors", Query: `SELECT sum("value") FROM "errors" WHERE time > now() - 1m`, Threshold: 5},
+	}
+
+	ok, metric, _ := mocks.ctrl.analyseCanary(cd)
+	if !ok {
+		t.Errorf("Got halted by %s wanted ok", metric)
+	}
+
+	cd.Spec.CanaryAnalysis.Metrics[0].Threshold = 1
+	if ok, _, _ := mocks.ctrl.analyseCanary(cd); ok {
+		t.Errorf("Got ok wanted halted by errors")
+	}
+
+	// the builtin checks require Prometheus or Datadog
+	cd.Spec.CanaryAnalysis.Metrics = []flaggerv1.CanaryMetric{{Name: "request-success-rate", Threshold: 99}}
+	if ok, _, _ := mocks.ctrl.analyseCanary(cd); ok {
+		t.Errorf("Got ok wanted halted by request-success-rate")
+	}
+}
+
+func TestScheduler_MetricsClientCache(t *testing.T) {
+	mocks := SetupMocks(nil)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "health-api", Namespace: "default", ResourceVersion: "1"},
+		Data:       map[string][]byte{"header_X-API-Key": []byte("secret")},
+	}
+	if _, err := mocks.kubeClient.CoreV1().Secrets("default").Create(secret); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	secretRef := &corev1.LocalObjectReference{Name: "health-api"}
+	options := metrics.ClientOptions{JSONPath: "$.score"}
+	client, err := mocks.ctrl.metricsClient("template/health-score.default", "json", "http://health-api", "default", secretRef, options)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// the client is reused while the settings and the secret are unchanged
+	cached, err := mocks.ctrl.metricsClient("template/health-score.default", "json", "http://health-api", "default", secretRef, options)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if cached != client {
+		t.Errorf("Got a new client wanted the cached one")
+	}
+
+	secret.ResourceVersion = "2"
+	if _, err := mocks.kubeClient.CoreV1().Secrets("default").Update(secret); err != nil {
+		t.Fatal(err.Error())
+	}
+	updated, err := mocks.ctrl.metricsClient("template/health-score.default", "json", "http://health-api", "default", secretRef, options)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if updated == client {
+		t.Errorf("Got the cached client wanted a new one after the secret update")
+	}
+
+	// the client is evicted when the template is deleted
+	mocks.ctrl.deleteMetricTemplate(&flaggerv1beta1.MetricTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "health-score", Namespace: "default"},
+	})
+	if _, ok := mocks.ctrl.metricsClients.Load("template/health-score.default"); ok {
+		t.Errorf("Got a cached client wanted none after the template deletion")
+	}
+}
+
+func TestScheduler_JSONMetricTemplate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json := fmt.Sprintf(`{"service":"%s","score":%d}`, r.URL.Query().Get("service"), 95)
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	mocks := SetupMocks(nil)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "health-api", Namespace: "default"},
+		Data:       map[string][]byte{"header_X-API-Key": []byte("secret")},
+	}
+	if _, err := mocks.kubeClient.CoreV1().Secrets("default").Create(secret); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	template := &flaggerv1beta1.MetricTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "health-score", Namespace: "default"},
+		Spec: flaggerv1beta1.MetricTemplateSpec{
+			Provider: flaggerv1beta1.MetricTemplateProvider{
+				Type:      "json",
+				Address:   ts.URL,
+				SecretRef: &corev1.LocalObjectReference{Name: "health-api"},
+				JSONPath:  "$.score",
+			},
+			Query: "/health?service={{ .Target }}&window={{ .Interval }}",
+		},
+	}
+	if _, err := mocks.flaggerClient.FlaggerV1beta1().MetricTemplates("default").Create(template); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	min := 90.0
+	cd := newTestCanary()
+	cd.Spec.CanaryAnalysis.Metrics = []flaggerv1.CanaryMetric{
+		{
+			Name:           "health-score",
+			ThresholdRange: &flaggerv1.CanaryThresholdRange{Min: &min},
+			TemplateRef:    &flaggerv1.MetricTemplateRef{Name: "health-score"},
+		},
+	}
+
+	ok, metric, _ := mocks.ctrl.analyseCanary(cd)
+	if !ok {
+		t.Errorf("Got halted by %s wanted ok", metric)
+	}
+
+	min = 99
+	if ok, _, _ := mocks.ctrl.analyseCanary(cd); ok {
+		t.Errorf("Got ok wanted halted by health-score")
+	}
+}
+
+func TestScheduler_ThresholdRange(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1545905245.458,"5"]}]}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	mocks := SetupMocks(nil)
+	one, ten, hundred := 1.0, 10.0, 100.0
+	tests := []struct {
+		thresholdRange flaggerv1.CanaryThresholdRange
+		ok             bool
+	}{
+		{thresholdRange: flaggerv1.CanaryThresholdRange{Min: &one, Max: &ten}, ok: true},
+		{thresholdRange: flaggerv1.CanaryThresholdRange{Min: &ten, Max: &hundred}, ok: false},
+		{thresholdRange: flaggerv1.CanaryThresholdRange{Max: &one}, ok: false},
+		{thresholdRange: flaggerv1.CanaryThresholdRange{Min: &one}, ok: true},
+	}
+
+	for i, test := range tests {
+		thresholdRange := test.thresholdRange
+		cd := newTestCanary()
+		cd.Spec.MetricsServer = ts.URL
+		cd.Spec.CanaryAnalysis.Metrics = []flaggerv1.CanaryMetric{
+			{
+				Name:           "throughput",
+				Query:          "sum(rate(http_requests_total[1m]))",
+				ThresholdRange: &thresholdRange,
+			},
+		}
+
+		if ok, _, _ := mocks.ctrl.analyseCanary(cd); ok != test.ok {
+			t.Errorf("Got ok %v wanted %v for range %d", ok, test.ok, i)
+		}
+	}
+}
+
+func TestScheduler_MetricAggregation(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var json string
+		switch r.URL.Path {
+		case "/api/v1/query":
+			json = `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"pod":"podinfo-1"},"value":[1545905245.458,"2"]},{"metric":{"pod":"podinfo-2"},"value":[1545905245.458,"8"]}]}}`
+		case "/api/v1/query_range":
+			json = `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"pod":"podinfo-1"},"values":[[1545905245,"1"],[1545905275,"2"],[1545905305,"9"]]},{"metric":{"pod":"podinfo-2"},"values":[[1545905245,"3"],[1545905275,"3"]]}]}}`
+		}
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	mocks := SetupMocks(nil)
+	percentile := 50.0
+	tests := []struct {
+		aggregation *flaggerv1.CanaryMetricAggregation
+		ok          bool
+	}{
+		// the second series is over the threshold
+		{aggregation: nil, ok: false},
+		{aggregation: &flaggerv1.CanaryMetricAggregation{Series: flaggerv1.CanaryMetricSeriesAny}, ok: false},
+		// avg of 2 and 8
+		{aggregation: &flaggerv1.CanaryMetricAggregation{Series: flaggerv1.CanaryMetricSeriesAggregate}, ok: true},
+		// series avg 4 and 3
+		{aggregation: &flaggerv1.CanaryMetricAggregation{Window: "10m", Step: "30s"}, ok: true},
+		// series max 9 and 3
+		{aggregation: &flaggerv1.CanaryMetricAggregation{Window: "10m", Function: "max"}, ok: false},
+		// series median 2 and 3
+		{aggregation: &flaggerv1.CanaryMetricAggregation{Window: "10m", Function: "percentile", Percentile: &percentile}, ok: true},
+		// last sample of the last series
+		{aggregation: &flaggerv1.CanaryMetricAggregation{Window: "10m", Function: "last", Series: flaggerv1.CanaryMetricSeriesAggregate}, ok: true},
+		// max across the series max 9 and 3
+		{aggregation: &flaggerv1.CanaryMetricAggregation{Window: "10m", Function: "max", Series: flaggerv1.CanaryMetricSeriesAggregate}, ok: false},
+	}
+
+	for i, test := range tests {
+		cd := newTestCanary()
+		cd.Spec.MetricsServer = ts.URL
+		cd.Spec.CanaryAnalysis.Metrics = []flaggerv1.CanaryMetric{
+			{
+				Name:        "pod latency",
+				Query:       "max(pod_latency_seconds) by (pod)",
+				Threshold:   5,
+				Aggregation: test.aggregation,
+			},
+		}
+
+		if ok, _, _ := mocks.ctrl.analyseCanary(cd); ok != test.ok {
+			t.Errorf("Got ok %v wanted %v for aggregation %d", ok, test.ok, i)
+		}
+	}
+}
+
+func TestScheduler_QueryVariables(t *testing.T) {
+	var queries []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("query"))
+		json := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1545905245.458,"1"]}]}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	mocks := SetupMocks(nil)
+	dep, err := mocks.kubeClient.AppsV1().Deployments("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	dep.Annotations = map[string]string{"deployment.kubernetes.io/revision": "2"}
+	if _, err := mocks.kubeClient.AppsV1().Deployments("default").Update(dep); err != nil {
+		t.Fatal(err.Error())
+	}
+	for revision, hash := range map[string]string{"1": "6b7f9c4d5f", "2": "5d8f9d8b7c"} {
+		rs := &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            fmt.Sprintf("podinfo-%s", hash),
+				Namespace:       "default",
+				Labels:          map[string]string{"app": "podinfo", "pod-template-hash": hash},
+				Annotations:     map[string]string{"deployment.kubernetes.io/revision": revision},
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(dep, appsv1.SchemeGroupVersion.WithKind("Deployment"))},
+			},
+		}
+		if _, err := mocks.kubeClient.AppsV1().ReplicaSets("default").Create(rs); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	cd := newTestCanary()
+	cd.Spec.MetricsServer = ts.URL
+	cd.Status.CanaryWeight = 20
+	cd.Spec.CanaryAnalysis.Metrics = []flaggerv1.CanaryMetric{
+		{
+			Name:      "route errors",
+			Threshold: 5,
+			Interval:  "1m",
+			Query: `sum(rate(http_errors_total{pod=~"{{ .Target }}-{{ .PodTemplateHash }}-.*",route="{{ .Variables.route }}"}[{{ .Interval }}]))` +
+				` / {{ .Weight }} + {{ .Port }}`,
+			Variables: map[string]string{"route": "/api"},
+		},
+	}
+
+	if ok, metric, _ := mocks.ctrl.analyseCanary(cd); !ok {
+		t.Errorf("Got halted by %s wanted ok", metric)
+	}
+	expected := `sum(rate(http_errors_total{pod=~"podinfo-5d8f9d8b7c-.*",route="/api"}[1m])) / 20 + 9898`
+	if len(queries) != 1 || queries[0] != expected {
+		t.Errorf("Got queries %v wanted %s", queries, expected)
+	}
+
+	// undefined variable
+	cd.Spec.CanaryAnalysis.Metrics[0].Variables = nil
+	if ok, _, _ := mocks.ctrl.analyseCanary(cd); ok {
+		t.Errorf("Got ok wanted halted by the render error")
+	}
+}
+
+func TestScheduler_PodTemplateHashKinds(t *testing.T) {
+	mocks := SetupMocks(nil)
+	kubeClient := mocks.kubeClient.(*fake.Clientset)
+
+	for _, kind := range []string{"DaemonSet", "StatefulSet"} {
+		cd := newTestCanary()
+		cd.Spec.TargetRef.Kind = kind
+		kubeClient.ClearActions()
+
+		if hash := mocks.ctrl.podTemplateHash(cd, "podinfo"); hash != "" {
+			t.Errorf("Got hash %s wanted empty for %s", hash, kind)
+		}
+		if actions := kubeClient.Actions(); len(actions) > 0 {
+			t.Errorf("Got %d API calls wanted none for %s", len(actions), kind)
+		}
+	}
+}
+
+func TestScheduler_MetricComparison(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := "90"
+		if strings.Contains(r.URL.Query().Get("query"), `destination_workload=~"podinfo-primary"`) {
+			value = "99"
+		}
+		json := fmt.Sprintf(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1545905245.458,"%s"]}]}}`, value)
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	mocks := SetupMocks(nil)
+	five, ten := 5.0, 10.0
+	tests := []struct {
+		comparison flaggerv1.CanaryMetricComparison
+		ok         bool
+	}{
+		{comparison: flaggerv1.CanaryMetricComparison{MaxDeviationPercent: &ten}, ok: true},
+		{comparison: flaggerv1.CanaryMetricComparison{MaxDeviationPercent: &five}, ok: false},
+		{comparison: flaggerv1.CanaryMetricComparison{MaxDelta: &ten}, ok: true},
+		{comparison: flaggerv1.CanaryMetricComparison{MaxDelta: &five}, ok: false},
+	}
+
+	for i, test := range tests {
+		comparison := test.comparison
+		cd := newTestCanary()
+		cd.Spec.MetricsServer = ts.URL
+		cd.Spec.CanaryAnalysis.Metrics = []flaggerv1.CanaryMetric{
+			{
+				Name:       "request-success-rate",
+				Interval:   "1m",
+				Comparison: &comparison,
+			},
+		}
+
+		if ok, _, _ := mocks.ctrl.analyseCanary(cd); ok != test.ok {
+			t.Errorf("Got ok %v wanted %v for comparison %d", ok, test.ok, i)
+		}
+	}
+}
+
+func TestScheduler_BuiltinMetrics(t *testing.T) {
+	var queries []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("query"))
+		json := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1545905245.458,"99.5"]}]}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	mocks := SetupMocks(nil)
+	percentile, objective, invalidObjective := 95.0, 99.9, 100.0
+	tests := []struct {
+		metric flaggerv1.CanaryMetric
+		ok     bool
+	}{
+		// 99.5 req/s
+		{metric: flaggerv1.CanaryMetric{Name: "request-rate", Threshold: 50}, ok: true},
+		{metric: flaggerv1.CanaryMetric{Name: "request-rate", Threshold: 100}, ok: false},
+		// 0.5% errors burn the 0.1% error budget 5 times faster
+		{metric: flaggerv1.CanaryMetric{Name: "error-budget-burn-rate", Threshold: 10, Objective: &objective}, ok: true},
+		{metric: flaggerv1.CanaryMetric{Name: "error-budget-burn-rate", Threshold: 2, Objective: &objective}, ok: false},
+		// the error budget is undefined without an objective under 100%
+		{metric: flaggerv1.CanaryMetric{Name: "error-budget-burn-rate", Threshold: 10}, ok: false},
+		{metric: flaggerv1.CanaryMetric{Name: "error-budget-burn-rate", Threshold: 10, Objective: &invalidObjective}, ok: false},
+		// 99.5s latency
+		{metric: flaggerv1.CanaryMetric{Name: "request-duration", Threshold: 100000, Percentile: &percentile}, ok: true},
+	}
+
+	for i, test := range tests {
+		cd := newTestCanary()
+		cd.Spec.MetricsServer = ts.URL
+		cd.Spec.CanaryAnalysis.Metrics = []flaggerv1.CanaryMetric{test.metric}
+
+		if ok, _, _ := mocks.ctrl.analyseCanary(cd); ok != test.ok {
+			t.Errorf("Got ok %v wanted %v for metric %d %s", ok, test.ok, i, test.metric.Name)
+		}
+	}
+
+	if last := queries[len(queries)-1]; !strings.Contains(last, "histogram_quantile( 0.95,") {
+		t.Errorf("Got query %s wanted the 0.95 quantile", last)
+	}
+}
+
+func TestScheduler_MetricComparisonDirection(t *testing.T) {
+	primaryValue := "99"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := "90"
+		if strings.Contains(r.URL.Query().Get("query"), `destination_workload=~"podinfo-primary"`) {
+			value = primaryValue
+		}
+		json := fmt.Sprintf(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1545905245.458,"%s"]}]}}`, value)
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	mocks := SetupMocks(nil)
+	five := 5.0
+	higher, lower := true, false
+	tests := []struct {
+		metric         string
+		primary        string
+		higherIsBetter *bool
+		ok             bool
+	}{
+		// a higher success rate is better
+		{metric: "request-success-rate", primary: "99", ok: false},
+		{metric: "request-success-rate", primary: "80", ok: true},
+		// a lower request duration is better
+		{metric: "request-duration", primary: "99", ok: true},
+		{metric: "request-duration", primary: "80", ok: false},
+		// the direction is explicit
+		{metric: "request-success-rate", primary: "99", higherIsBetter: &lower, ok: true},
+		{metric: "request-duration", primary: "99", higherIsBetter: &higher, ok: false},
+		// the request rate depends on the traffic weight
+		{metric: "request-rate", primary: "90", ok: false},
+	}
+
+	for i, test := range tests {
+		primaryValue = test.primary
+		cd := newTestCanary()
+		cd.Spec.MetricsServer = ts.URL
+		cd.Spec.CanaryAnalysis.Metrics = []flaggerv1.CanaryMetric{
+			{
+				Name:     test.metric,
+				Interval: "1m",
+				Comparison: &flaggerv1.CanaryMetricComparison{
+					MaxDeviationPercent: &five,
+					HigherIsBetter:      test.higherIsBetter,
+				},
+			},
+		}
+
+		if ok, _, _ := mocks.ctrl.analyseCanary(cd); ok != test.ok {
+			t.Errorf("Got ok %v wanted %v for %s comparison %d", ok, test.ok, test.metric, i)
+		}
+	}
+}
+
+func TestScheduler_KubernetesDefaultsWarnings(t *testing.T) {
+	canary := newTestCanary()
+	canary.Spec.Provider = "kubernetes"
+	canary.Spec.CanaryAnalysis.Iterations = 0
+	mocks := SetupMocks(canary)
+	recorder := record.NewFakeRecorder(100)
+	mocks.ctrl.eventRecorder = recorder
+
+	// init
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	// update
+	dep2 := newTestDeploymentV2()
+	_, err := mocks.kubeClient.AppsV1().Deployments("default").Update(dep2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// detect pod spec changes
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	// advance
+	mocks.ctrl.advanceCanary("podinfo", "default", true)
+
+	// the defaults are not written back with the status updates
+	cd, err := mocks.flaggerClient.FlaggerV1alpha3().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if cd.Spec.CanaryAnalysis.Iterations != 0 {
+		t.Errorf("Got iterations %v wanted %v", cd.Spec.CanaryAnalysis.Iterations, 0)
+	}
+	if cd.Status.Iterations != 1 {
+		t.Errorf("Got status iterations %v wanted %v", cd.Status.Iterations, 1)
+	}
+
+	var events []string
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	for _, expected := range []string{
+		"Progressive traffic is not supported when using the kubernetes provider",
+		"Setting canaryAnalysis.iterations: 10",
+	} {
+		found := false
+		for _, event := range events {
+			if strings.Contains(event, expected) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Got events %v wanted %s", events, expected)
+		}
+	}
+}
@@ -45,7 +45,7 @@ func CallWebhook(name string, namespace string, phase flaggerv1.CanaryPhase, w f
 	req.Header.Set("Content-Type", "application/json")
 
 	if len(w.Timeout) < 2 {
-		w.Timeout = "10s"
+		w.Timeout = flaggerv1.WebhookTimeout
 	}
 
 	timeout, err := time.ParseDuration(w.Timeout)
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// MinAggregation returns the smallest value
+	MinAggregation = "min"
+	// MaxAggregation returns the largest value
+	MaxAggregation = "max"
+	// AvgAggregation returns the mean of the values
+	AvgAggregation = "avg"
+	// LastAggregation returns the last value
+	LastAggregation = "last"
+	// PercentileAggregation returns the percentile of the values
+	PercentileAggregation = "percentile"
+)
+
+// IsSupportedAggregation returns true if the aggregation function is implemented by Aggregate
+func IsSupportedAggregation(function string) bool {
+	switch function {
+	case "", MinAggregation, MaxAggregation, AvgAggregation, LastAggregation, PercentileAggregation:
+		return true
+	}
+	return false
+}
+
+// Aggregate reduces the values to a single one with the aggregation function, defaults to avg,
+// the percentile between 0 and 100 is only used by the percentile function
+func Aggregate(values []float64, function string, percentile float64) (float64, error) {
+	if len(values) == 0 {
+		return 0, fmt.Errorf("no values found")
+	}
+
+	switch function {
+	case MinAggregation:
+		min := values[0]
+		for _, v := range values[1:] {
+			min = math.Min(min, v)
+		}
+		return min, nil
+	case MaxAggregation:
+		max := values[0]
+		for _, v := range values[1:] {
+			max = math.Max(max, v)
+		}
+		return max, nil
+	case "", AvgAggregation:
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		return sum / float64(len(values)), nil
+	case LastAggregation:
+		return values[len(values)-1], nil
+	case PercentileAggregation:
+		if percentile < 0 || percentile > 100 {
+			return 0, fmt.Errorf("percentile %v must be between 0 and 100", percentile)
+		}
+		sorted := append([]float64(nil), values...)
+		sort.Float64s(sorted)
+
+		// linear interpolation between the closest ranks
+		rank := percentile / 100 * float64(len(sorted)-1)
+		lower := int(math.Floor(rank))
+		upper := int(math.Ceil(rank))
+		return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower)), nil
+	}
+	return 0, fmt.Errorf("aggregation %s not supported", function)
+}
+
+// seriesClient is implemented by the clients of the providers that return
+// the values of every series instead of a single value
+type seriesClient interface {
+	runSeriesQuery(query string) ([]float64, error)
+	runRangeSeriesQuery(query string, start time.Time, end time.Time, step time.Duration) ([][]float64, error)
+}
+
+// QuerySeries executes the query and returns the value of every series of the result,
+// the result of the clients that don't tell the series apart is returned as a single series
+func QuerySeries(client Client, query string) ([]float64, error) {
+	if c, ok := client.(seriesClient); ok {
+		return c.runSeriesQuery(query)
+	}
+
+	value, err := client.RunQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return []float64{value}, nil
+}
+
+// QueryRangeSeries executes the query over the time range and returns the samples of every series,
+// the samples of the clients that don't tell the series apart are returned as a single series
+func QueryRangeSeries(client Client, query string, start time.Time, end time.Time, step time.Duration) ([][]float64, error) {
+	if c, ok := client.(seriesClient); ok {
+		return c.runRangeSeriesQuery(query, start, end, step)
+	}
+
+	values, err := client.RunRangeQuery(query, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	return [][]float64{values}, nil
+}
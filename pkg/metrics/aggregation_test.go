@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAggregate(t *testing.T) {
+	values := []float64{4, 1, 3, 2, 5}
+	tests := []struct {
+		function   string
+		percentile float64
+		expected   float64
+	}{
+		{function: MinAggregation, expected: 1},
+		{function: MaxAggregation, expected: 5},
+		{function: AvgAggregation, expected: 3},
+		{function: "", expected: 3},
+		{function: LastAggregation, expected: 5},
+		{function: PercentileAggregation, percentile: 50, expected: 3},
+		{function: PercentileAggregation, percentile: 90, expected: 4.6},
+		{function: PercentileAggregation, percentile: 100, expected: 5},
+	}
+
+	for _, tt := range tests {
+		val, err := Aggregate(values, tt.function, tt.percentile)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if val < tt.expected-0.0001 || val > tt.expected+0.0001 {
+			t.Errorf("Got %v wanted %v for %s %v", val, tt.expected, tt.function, tt.percentile)
+		}
+	}
+
+	if _, err := Aggregate(values, "sum", 0); err == nil {
+		t.Errorf("Got no error wanted aggregation not supported")
+	}
+	if _, err := Aggregate(nil, AvgAggregation, 0); err == nil || err.Error() != "no values found" {
+		t.Errorf("Got error %v wanted %v", err, "no values found")
+	}
+}
+
+func TestQuerySeries_Prometheus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var json string
+		switch r.URL.Path {
+		case "/api/v1/query":
+			json = `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"pod":"podinfo-1"},"value":[1545905245.458,"0.2"]},{"metric":{"pod":"podinfo-2"},"value":[1545905245.458,"1.5"]}]}}`
+		case "/api/v1/query_range":
+			json = `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"pod":"podinfo-1"},"values":[[1545905245,"1"],[1545905275,"3"]]},{"metric":{"pod":"podinfo-2"},"values":[[1545905245,"NaN"]]},{"metric":{"pod":"podinfo-3"},"values":[[1545905245,"5"]]}]}}`
+		}
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := NewPrometheusClient(ts.URL, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := QuerySeries(client, `max(pod_latency_seconds) by (pod)`)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(values) != 2 || values[0] != 0.2 || values[1] != 1.5 {
+		t.Errorf("Got %v wanted %v", values, []float64{0.2, 1.5})
+	}
+
+	end := time.Now()
+	series, err := QueryRangeSeries(client, `max(pod_latency_seconds) by (pod)`, end.Add(-time.Minute), end, 30*time.Second)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(series) != 2 || len(series[0]) != 2 || series[1][0] != 5 {
+		t.Errorf("Got %v wanted %v", series, [][]float64{{1, 3}, {5}})
+	}
+}
+
+func TestQuerySeries_SingleValueClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"health":{"score":97.5}}`))
+	}))
+	defer ts.Close()
+
+	client, err := NewJSONClient(ts.URL, nil, ClientOptions{JSONPath: ".health.score"}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := QuerySeries(client, "/")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(values) != 1 || values[0] != 97.5 {
+		t.Errorf("Got %v wanted %v", values, []float64{97.5})
+	}
+}
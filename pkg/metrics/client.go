@@ -4,22 +4,47 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"net/url"
 	"path"
 	"regexp"
 	"strconv"
+	"strings"
 	"text/template"
 	"time"
 )
 
+const (
+	// PrometheusUsernameSecretKey is the Secret key that holds the basic auth user name
+	PrometheusUsernameSecretKey = "prometheus_username"
+	// PrometheusPasswordSecretKey is the Secret key that holds the basic auth password
+	PrometheusPasswordSecretKey = "prometheus_password"
+	// PrometheusTokenSecretKey is the Secret key that holds the bearer token
+	PrometheusTokenSecretKey = "prometheus_token"
+	// PrometheusCASecretKey is the Secret key that holds the PEM encoded CA bundle of the server certificate
+	PrometheusCASecretKey = "ca.crt"
+	// PrometheusCertSecretKey is the Secret key that holds the PEM encoded client certificate
+	PrometheusCertSecretKey = "tls.crt"
+	// PrometheusKeySecretKey is the Secret key that holds the PEM encoded client key
+	PrometheusKeySecretKey = "tls.key"
+	// PrometheusInsecureSkipVerifySecretKey is the Secret key that disables the server certificate verification when set to true
+	PrometheusInsecureSkipVerifySecretKey = "insecure_skip_verify"
+)
+
 // PrometheusClient is executing promql queries
 type PrometheusClient struct {
-	timeout time.Duration
-	url     url.URL
+	timeout  time.Duration
+	url      url.URL
+	client   *http.Client
+	username string
+	password string
+	token    string
 }
 
 type prometheusResponse struct {
@@ -28,41 +53,127 @@ type prometheusResponse struct {
 			Metric struct {
 				Name string `json:"name"`
 			}
-			Value []interface{} `json:"value"`
+			Value  []interface{}   `json:"value"`
+			Values [][]interface{} `json:"values"`
 		}
 	}
 }
 
 // NewPrometheusClient creates a Prometheus client for the provided URL address
 func NewPrometheusClient(address string, timeout time.Duration) (*PrometheusClient, error) {
+	return NewPrometheusClientWithCredentials(address, nil, timeout)
+}
+
+// NewPrometheusClientWithCredentials creates a Prometheus client that authenticates with basic auth
+// or a bearer token and verifies the server with a custom CA or presents a client certificate,
+// the credentials are the data of the Secret referenced by the provider
+func NewPrometheusClientWithCredentials(address string, credentials map[string][]byte, timeout time.Duration) (*PrometheusClient, error) {
 	promURL, err := url.Parse(address)
 	if err != nil {
 		return nil, err
 	}
 
-	return &PrometheusClient{timeout: timeout, url: *promURL}, nil
+	client := http.DefaultClient
+	if tlsConfig, err := prometheusTLSConfig(credentials); err != nil {
+		return nil, err
+	} else if tlsConfig != nil {
+		// start from the default transport settings so that the idle connections are closed
+		// when the client is replaced after a change of the credentials
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		client = &http.Client{Transport: transport}
+	}
+
+	return &PrometheusClient{
+		timeout:  timeout,
+		url:      *promURL,
+		client:   client,
+		username: string(credentials[PrometheusUsernameSecretKey]),
+		password: string(credentials[PrometheusPasswordSecretKey]),
+		token:    string(credentials[PrometheusTokenSecretKey]),
+	}, nil
+}
+
+// prometheusTLSConfig returns the TLS settings of the credentials or nil if there are none
+func prometheusTLSConfig(credentials map[string][]byte) (*tls.Config, error) {
+	ca, cert, key := credentials[PrometheusCASecretKey], credentials[PrometheusCertSecretKey], credentials[PrometheusKeySecretKey]
+	insecure := strings.TrimSpace(string(credentials[PrometheusInsecureSkipVerifySecretKey])) == "true"
+	if len(ca) == 0 && len(cert) == 0 && len(key) == 0 && !insecure {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecure}
+	if len(ca) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("error parsing the %s CA bundle", PrometheusCASecretKey)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if len(cert) > 0 || len(key) > 0 {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing the %s and %s client certificate: %v",
+				PrometheusCertSecretKey, PrometheusKeySecretKey, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	}
+
+	return tlsConfig, nil
 }
 
 // RenderQuery renders the promql query using the provided text template
-func (p *PrometheusClient) RenderQuery(name string, namespace string, interval string, tmpl string) (string, error) {
-	meta := struct {
-		Name      string
-		Namespace string
-		Interval  string
-	}{
-		name,
-		namespace,
-		interval,
+func (p *PrometheusClient) RenderQuery(model MetricTemplateModel, tmpl string) (string, error) {
+	return renderTemplate(tmpl, model)
+}
+
+// MetricTemplateModel holds the canary variables available in the builtin, custom and MetricTemplate queries
+type MetricTemplateModel struct {
+	// Name of the workload the query runs for, the canary target or the primary
+	Name string
+	// Namespace of the canary
+	Namespace string
+	// Interval of the metric
+	Interval string
+	// Canary resource name
+	Canary string
+	// Target name of the workload the query runs for, same as Name
+	Target string
+	// Primary name of the primary workload
+	Primary string
+	// Ingress name of the ingress referenced by the canary
+	Ingress string
+	// Port of the canary service
+	Port int32
+	// Weight of the traffic routed to the canary
+	Weight int
+	// PodTemplateHash of the current ReplicaSet of the named deployment
+	PodTemplateHash string
+	// Percentile of the request duration, the builtin queries default to 99
+	Percentile float64
+	// Variables defined in the metric spec
+	Variables map[string]string
+}
+
+// Quantile returns the request duration percentile as a quantile between 0 and 1, defaults to 0.99
+func (m MetricTemplateModel) Quantile() float64 {
+	if m.Percentile == 0 {
+		return 0.99
 	}
+	// round off the floating point error of the division, e.g. 99.9 / 100
+	return math.Round(m.Percentile*1e8) / 1e10
+}
 
-	t, err := template.New("tmpl").Parse(tmpl)
+// renderTemplate renders the query, the references to variables that aren't defined fail the rendering
+func renderTemplate(tmpl string, data interface{}) (string, error) {
+	t, err := template.New("tmpl").Option("missingkey=error").Parse(tmpl)
 	if err != nil {
 		return "", err
 	}
-	var data bytes.Buffer
-	b := bufio.NewWriter(&data)
+	var buf bytes.Buffer
+	b := bufio.NewWriter(&buf)
 
-	if err := t.Execute(b, meta); err != nil {
+	if err := t.Execute(b, data); err != nil {
 		return "", err
 	}
 
@@ -71,19 +182,116 @@ func (p *PrometheusClient) RenderQuery(name string, namespace string, interval s
 		return "", err
 	}
 
-	return data.String(), nil
+	return buf.String(), nil
 }
 
-// RunQuery executes the promql and converts the result to float64
+// RunQuery executes the promql and converts the result to float64,
+// the value of the last series is returned when the result has more than one series
 func (p *PrometheusClient) RunQuery(query string) (float64, error) {
+	values, err := p.runSeriesQuery(query)
+	if err != nil {
+		return 0, err
+	}
+
+	return values[len(values)-1], nil
+}
+
+// runSeriesQuery executes the promql and returns the value of every series of the result
+func (p *PrometheusClient) runSeriesQuery(query string) ([]float64, error) {
 	if p.url.Host == "fake" {
-		return 100, nil
+		return []float64{100}, nil
 	}
 
 	query = url.QueryEscape(p.TrimQuery(query))
-	u, err := url.Parse(fmt.Sprintf("./api/v1/query?query=%s", query))
+	result, err := p.get(fmt.Sprintf("./api/v1/query?query=%s", query))
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+
+	var values []float64
+	for _, v := range result.Data.Result {
+		metricValue := v.Value[1]
+		switch metricValue.(type) {
+		case string:
+			f, err := strconv.ParseFloat(metricValue.(string), 64)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, f)
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no values found")
+	}
+
+	return values, nil
+}
+
+// RunRangeQuery executes the promql over the time range and returns the samples of all the series
+func (p *PrometheusClient) RunRangeQuery(query string, start time.Time, end time.Time, step time.Duration) ([]float64, error) {
+	series, err := p.runRangeSeriesQuery(query, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []float64
+	for _, s := range series {
+		values = append(values, s...)
+	}
+	return values, nil
+}
+
+// runRangeSeriesQuery executes the promql over the time range and returns the samples of every series,
+// the series without samples are left out
+func (p *PrometheusClient) runRangeSeriesQuery(query string, start time.Time, end time.Time, step time.Duration) ([][]float64, error) {
+	if p.url.Host == "fake" {
+		return [][]float64{{100}}, nil
+	}
+
+	params := url.Values{}
+	params.Set("query", p.TrimQuery(query))
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+	result, err := p.get(fmt.Sprintf("./api/v1/query_range?%s", params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	var series [][]float64
+	for _, r := range result.Data.Result {
+		var values []float64
+		for _, v := range r.Values {
+			if len(v) < 2 {
+				continue
+			}
+			if s, ok := v[1].(string); ok {
+				f, err := strconv.ParseFloat(s, 64)
+				if err != nil {
+					return nil, err
+				}
+				// the ratio queries return NaN when there is no traffic
+				if !math.IsNaN(f) {
+					values = append(values, f)
+				}
+			}
+		}
+		if len(values) > 0 {
+			series = append(series, values)
+		}
+	}
+	if len(series) == 0 {
+		return nil, fmt.Errorf("no values found")
+	}
+
+	return series, nil
+}
+
+// get calls the Prometheus API and decodes the query result
+func (p *PrometheusClient) get(api string) (*prometheusResponse, error) {
+	u, err := url.Parse(api)
+	if err != nil {
+		return nil, err
 	}
 	u.Path = path.Join(p.url.Path, u.Path)
 
@@ -91,54 +299,42 @@ func (p *PrometheusClient) RunQuery(query string) (float64, error) {
 
 	req, err := http.NewRequest("GET", u.String(), nil)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	ctx, cancel := context.WithTimeout(req.Context(), p.timeout)
 	defer cancel()
 
-	r, err := http.DefaultClient.Do(req.WithContext(ctx))
+	r, err := p.do(req.WithContext(ctx))
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	defer r.Body.Close()
 
 	b, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		return 0, fmt.Errorf("error reading body: %s", err.Error())
+		return nil, fmt.Errorf("error reading body: %s", err.Error())
 	}
 
 	if 400 <= r.StatusCode {
-		return 0, fmt.Errorf("error response: %s", string(b))
+		return nil, fmt.Errorf("error response: %s", string(b))
 	}
 
 	var result prometheusResponse
 	err = json.Unmarshal(b, &result)
 	if err != nil {
-		return 0, fmt.Errorf("error unmarshaling result: %s, '%s'", err.Error(), string(b))
-	}
-
-	var value *float64
-	for _, v := range result.Data.Result {
-		metricValue := v.Value[1]
-		switch metricValue.(type) {
-		case string:
-			f, err := strconv.ParseFloat(metricValue.(string), 64)
-			if err != nil {
-				return 0, err
-			}
-			value = &f
-		}
-	}
-	if value == nil {
-		return 0, fmt.Errorf("no values found")
+		return nil, fmt.Errorf("error unmarshaling result: %s, '%s'", err.Error(), string(b))
 	}
 
-	return *value, nil
+	return &result, nil
 }
 
 // TrimQuery takes a promql query and removes whitespace
 func (p *PrometheusClient) TrimQuery(query string) string {
+	return trimQuery(query)
+}
+
+func trimQuery(query string) string {
 	space := regexp.MustCompile(`\s+`)
 	return space.ReplaceAllString(query, " ")
 }
@@ -161,7 +357,7 @@ func (p *PrometheusClient) IsOnline() (bool, error) {
 	ctx, cancel := context.WithTimeout(req.Context(), p.timeout)
 	defer cancel()
 
-	r, err := http.DefaultClient.Do(req.WithContext(ctx))
+	r, err := p.do(req.WithContext(ctx))
 	if err != nil {
 		return false, err
 	}
@@ -179,6 +375,21 @@ func (p *PrometheusClient) IsOnline() (bool, error) {
 	return true, nil
 }
 
+// do sends the request with the client credentials
+func (p *PrometheusClient) do(req *http.Request) (*http.Response, error) {
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	} else if p.username != "" {
+		req.SetBasicAuth(p.username, p.password)
+	}
+
+	client := p.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
 func (p *PrometheusClient) GetMetricsServer() string {
 	return p.url.String()
 }
@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"encoding/base64"
+	"encoding/pem"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -41,6 +43,35 @@ func TestPrometheusClient_RunQuery(t *testing.T) {
 	}
 }
 
+func TestPrometheusClient_RunRangeQuery(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query_range" {
+			t.Errorf("Got path %s wanted %s", r.URL.Path, "/api/v1/query_range")
+		}
+		if step := r.URL.Query().Get("step"); step != "30" {
+			t.Errorf("Got step %s wanted %s", step, "30")
+		}
+		json := `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[1545905245,"99"],[1545905275,"NaN"],[1545905305,"98.5"]]}]}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := NewPrometheusClient(ts.URL, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	end := time.Now()
+	values, err := client.RunRangeQuery(`sum(rate(http_requests_total[1m]))`, end.Add(-time.Minute), end, 30*time.Second)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(values) != 2 || values[0] != 99 || values[1] != 98.5 {
+		t.Errorf("Got %v wanted %v", values, []float64{99, 98.5})
+	}
+}
+
 func TestPrometheusClient_IsOnline(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		json := `{"status":"success","data":{"config.file":"/etc/prometheus/prometheus.yml"}}`
@@ -83,3 +114,133 @@ func TestPrometheusClient_IsOffline(t *testing.T) {
 		t.Errorf("Got %v wanted %v", ok, false)
 	}
 }
+
+func TestPrometheusClient_Credentials(t *testing.T) {
+	tests := []struct {
+		credentials map[string][]byte
+		auth        string
+	}{
+		{
+			credentials: map[string][]byte{
+				PrometheusUsernameSecretKey: []byte("flagger"),
+				PrometheusPasswordSecretKey: []byte("secret"),
+			},
+			auth: "Basic " + base64.StdEncoding.EncodeToString([]byte("flagger:secret")),
+		},
+		{
+			credentials: map[string][]byte{PrometheusTokenSecretKey: []byte("token")},
+			auth:        "Bearer token",
+		},
+	}
+
+	for _, tt := range tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if auth := r.Header.Get("Authorization"); auth != tt.auth {
+				t.Errorf("Got authorization %s wanted %s", auth, tt.auth)
+			}
+			json := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1545905245.458,"100"]}]}}`
+			w.Write([]byte(json))
+		}))
+
+		client, err := NewPrometheusClientWithCredentials(ts.URL, tt.credentials, time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := client.RunQuery(`sum(rate(http_requests_total[1m]))`); err != nil {
+			t.Errorf("Got error %v for %s", err, tt.auth)
+		}
+		if _, err := client.IsOnline(); err != nil {
+			t.Errorf("Got error %v for %s", err, tt.auth)
+		}
+		ts.Close()
+	}
+}
+
+func TestPrometheusClient_TLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json := `{"status":"success","data":{"config.file":"/etc/prometheus/prometheus.yml"}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+
+	client, err := NewPrometheusClientWithCredentials(ts.URL, map[string][]byte{PrometheusCASecretKey: ca}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := client.IsOnline(); !ok {
+		t.Errorf("Got error %v wanted the server certificate to be verified with the CA", err)
+	}
+
+	client, err = NewPrometheusClientWithCredentials(ts.URL,
+		map[string][]byte{PrometheusInsecureSkipVerifySecretKey: []byte("true")}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := client.IsOnline(); !ok {
+		t.Errorf("Got error %v wanted the server certificate verification to be skipped", err)
+	}
+
+	client, err = NewPrometheusClient(ts.URL, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := client.IsOnline(); ok {
+		t.Errorf("Got no error wanted unknown certificate authority")
+	}
+
+	if _, err := NewPrometheusClientWithCredentials(ts.URL, map[string][]byte{PrometheusCASecretKey: []byte("ca")}, time.Second); err == nil {
+		t.Errorf("Got no error wanted CA parse error")
+	}
+	if _, err := NewPrometheusClientWithCredentials(ts.URL, map[string][]byte{PrometheusCertSecretKey: ca}, time.Second); err == nil {
+		t.Errorf("Got no error wanted client certificate parse error")
+	}
+}
+
+func TestPrometheusClient_RenderQuery(t *testing.T) {
+	client, err := NewPrometheusClient("http://prometheus:9090", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	model := MetricTemplateModel{
+		Name:            "podinfo",
+		Namespace:       "test",
+		Interval:        "1m",
+		Canary:          "podinfo",
+		Target:          "podinfo",
+		Primary:         "podinfo-primary",
+		Port:            9898,
+		Weight:          20,
+		PodTemplateHash: "5d8f9d8b7c",
+		Variables:       map[string]string{"route": "/api"},
+	}
+
+	query, err := client.RenderQuery(model,
+		`sum(rate(http_requests_total{namespace="{{ .Namespace }}",pod!~"{{ .Primary }}-.*"}[{{ .Interval }}]))`)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := `sum(rate(http_requests_total{namespace="test",pod!~"podinfo-primary-.*"}[1m]))`
+	if query != expected {
+		t.Errorf("Got %s wanted %s", query, expected)
+	}
+
+	query, err = client.RenderQuery(model,
+		`sum(rate(http_requests_total{pod=~"{{ .Name }}-{{ .PodTemplateHash }}-.*",port="{{ .Port }}",route="{{ .Variables.route }}"}[{{ .Interval }}])) / {{ .Weight }}`)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected = `sum(rate(http_requests_total{pod=~"podinfo-5d8f9d8b7c-.*",port="9898",route="/api"}[1m])) / 20`
+	if query != expected {
+		t.Errorf("Got %s wanted %s", query, expected)
+	}
+
+	if _, err := client.RenderQuery(model, `http_requests_total{route="{{ .Variables.path }}"}`); err == nil {
+		t.Errorf("Got no error wanted undefined variable path")
+	}
+}
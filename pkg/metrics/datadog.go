@@ -0,0 +1,321 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+)
+
+const (
+	// DatadogDefaultAddress is the Datadog API address used when the canary doesn't specify one
+	DatadogDefaultAddress = "https://api.datadoghq.com"
+	// DatadogAPIKeySecretKey is the Secret key that holds the Datadog API key
+	DatadogAPIKeySecretKey = "datadog_api_key"
+	// DatadogApplicationKeySecretKey is the Secret key that holds the Datadog application key
+	DatadogApplicationKeySecretKey = "datadog_application_key"
+)
+
+// the builtin queries use the Datadog APM metrics tagged with the Kubernetes deployment
+var datadogQueries = map[string]string{
+	"request-success-rate": `
+	100 - (
+		sum:trace.http.request.errors{kube_namespace:{{ .Namespace }},kube_deployment:{{ .Name }}}.as_count()
+		/
+		sum:trace.http.request.hits{kube_namespace:{{ .Namespace }},kube_deployment:{{ .Name }}}.as_count()
+	) * 100`,
+	"request-duration": `
+	avg:trace.http.request.duration.by.service.{{ .Percentile }}p{kube_namespace:{{ .Namespace }},kube_deployment:{{ .Name }}}`,
+	"request-rate": `
+	sum:trace.http.request.hits{kube_namespace:{{ .Namespace }},kube_deployment:{{ .Name }}}.as_rate()`,
+}
+
+// datadogPercentiles are the percentiles of the APM request duration metrics
+var datadogPercentiles = map[float64]bool{50: true, 75: true, 90: true, 95: true, 99: true}
+
+// datadogModel sets the default percentile of the request duration and rejects the ones not computed by APM
+func datadogModel(model MetricTemplateModel) (MetricTemplateModel, error) {
+	if model.Percentile == 0 {
+		model.Percentile = 99
+	}
+	if !datadogPercentiles[model.Percentile] {
+		return model, fmt.Errorf("percentile %v not supported by the datadog provider, can be 50, 75, 90, 95 or 99", model.Percentile)
+	}
+	return model, nil
+}
+
+// DatadogClient is executing queries with the Datadog metrics API
+type DatadogClient struct {
+	timeout        time.Duration
+	url            url.URL
+	apiKey         string
+	applicationKey string
+	// interval is the time range of the queries
+	interval time.Duration
+}
+
+type datadogResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Series []struct {
+		Pointlist [][]*float64 `json:"pointlist"`
+	} `json:"series"`
+}
+
+// NewDatadogClient creates a Datadog client for the provided API address,
+// the API and application keys are read from the provider credentials
+func NewDatadogClient(address string, credentials map[string][]byte, timeout time.Duration) (*DatadogClient, error) {
+	if address == "" {
+		address = DatadogDefaultAddress
+	}
+	ddURL, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, ok := credentials[DatadogAPIKeySecretKey]
+	if !ok {
+		return nil, fmt.Errorf("datadog credentials don't contain the %s key", DatadogAPIKeySecretKey)
+	}
+	applicationKey, ok := credentials[DatadogApplicationKeySecretKey]
+	if !ok {
+		return nil, fmt.Errorf("datadog credentials don't contain the %s key", DatadogApplicationKeySecretKey)
+	}
+
+	return &DatadogClient{
+		timeout:        timeout,
+		url:            *ddURL,
+		apiKey:         string(apiKey),
+		applicationKey: string(applicationKey),
+		interval:       time.Minute,
+	}, nil
+}
+
+func (d *DatadogClient) withInterval(interval time.Duration) Client {
+	client := *d
+	client.interval = interval
+	return &client
+}
+
+// RenderQuery renders the Datadog query using the provided text template
+func (d *DatadogClient) RenderQuery(model MetricTemplateModel, tmpl string) (string, error) {
+	return renderTemplate(tmpl, model)
+}
+
+// RunQuery executes the query over the client interval and returns the last point of the last series
+func (d *DatadogClient) RunQuery(query string) (float64, error) {
+	values, err := d.runSeriesQuery(query)
+	if err != nil {
+		return 0, err
+	}
+
+	return values[len(values)-1], nil
+}
+
+// RunRangeQuery executes the query over the time range and returns the points of all the series,
+// the resolution of the series is chosen by Datadog based on the time range
+func (d *DatadogClient) RunRangeQuery(query string, start time.Time, end time.Time, step time.Duration) ([]float64, error) {
+	series, err := d.query(query, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []float64
+	for _, s := range series {
+		values = append(values, s...)
+	}
+	return values, nil
+}
+
+// runSeriesQuery executes the query over the client interval and returns the last point of every series
+func (d *DatadogClient) runSeriesQuery(query string) ([]float64, error) {
+	now := time.Now()
+	series, err := d.query(query, now.Add(-d.interval), now)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]float64, 0, len(series))
+	for _, s := range series {
+		values = append(values, s[len(s)-1])
+	}
+	return values, nil
+}
+
+// runRangeSeriesQuery executes the query over the time range and returns the points of every series
+func (d *DatadogClient) runRangeSeriesQuery(query string, start time.Time, end time.Time, step time.Duration) ([][]float64, error) {
+	return d.query(query, start, end)
+}
+
+// query returns the points of the series that have data in the time range
+func (d *DatadogClient) query(query string, from time.Time, to time.Time) ([][]float64, error) {
+	params := url.Values{}
+	params.Set("query", trimQuery(query))
+	params.Set("from", strconv.FormatInt(from.Unix(), 10))
+	params.Set("to", strconv.FormatInt(to.Unix(), 10))
+
+	b, err := d.get("./api/v1/query", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result datadogResponse
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, fmt.Errorf("error unmarshaling result: %s, '%s'", err.Error(), string(b))
+	}
+	if result.Status == "error" {
+		return nil, fmt.Errorf("error response: %s", result.Error)
+	}
+
+	var series [][]float64
+	for _, s := range result.Series {
+		var values []float64
+		for _, point := range s.Pointlist {
+			// points are [timestamp, value] pairs and the value is null when there is no data
+			if len(point) < 2 || point[1] == nil {
+				continue
+			}
+			values = append(values, *point[1])
+		}
+		if len(values) > 0 {
+			series = append(series, values)
+		}
+	}
+	if len(series) == 0 {
+		return nil, fmt.Errorf("no values found")
+	}
+
+	return series, nil
+}
+
+// IsOnline calls the Datadog validate endpoint and returns an error if the API key is rejected
+func (d *DatadogClient) IsOnline() (bool, error) {
+	if _, err := d.get("./api/v1/validate", url.Values{}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetMetricsServer returns the Datadog API address
+func (d *DatadogClient) GetMetricsServer() string {
+	return d.url.String()
+}
+
+// get calls the Datadog API with the client keys and returns the response body
+func (d *DatadogClient) get(api string, params url.Values) ([]byte, error) {
+	u, err := url.Parse(api)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(d.url.Path, u.Path)
+	u.RawQuery = params.Encode()
+
+	u = d.url.ResolveReference(u)
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("DD-API-KEY", d.apiKey)
+	req.Header.Set("DD-APPLICATION-KEY", d.applicationKey)
+
+	ctx, cancel := context.WithTimeout(req.Context(), d.timeout)
+	defer cancel()
+
+	r, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading body: %s", err.Error())
+	}
+
+	if 400 <= r.StatusCode {
+		return nil, fmt.Errorf("error response: %s", string(b))
+	}
+
+	return b, nil
+}
+
+type DatadogObserver struct {
+	client Client
+}
+
+func (ob *DatadogObserver) GetRequestSuccessRate(model MetricTemplateModel) (float64, error) {
+	query, err := ob.client.RenderQuery(model, datadogQueries["request-success-rate"])
+	if err != nil {
+		return 0, err
+	}
+
+	client, err := ForInterval(ob.client, model.Interval)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := client.RunQuery(query)
+	if err != nil {
+		return 0, err
+	}
+
+	return value, nil
+}
+
+func (ob *DatadogObserver) GetRequestRate(model MetricTemplateModel) (float64, error) {
+	query, err := ob.client.RenderQuery(model, datadogQueries["request-rate"])
+	if err != nil {
+		return 0, err
+	}
+
+	client, err := ForInterval(ob.client, model.Interval)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := client.RunQuery(query)
+	if err != nil {
+		return 0, err
+	}
+
+	return value, nil
+}
+
+func (ob *DatadogObserver) GetRequestDuration(model MetricTemplateModel) (time.Duration, error) {
+	model, err := datadogModel(model)
+	if err != nil {
+		return 0, err
+	}
+
+	query, err := ob.client.RenderQuery(model, datadogQueries["request-duration"])
+	if err != nil {
+		return 0, err
+	}
+
+	client, err := ForInterval(ob.client, model.Interval)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := client.RunQuery(query)
+	if err != nil {
+		return 0, err
+	}
+
+	ms := time.Duration(int64(value*1000)) * time.Millisecond
+	return ms, nil
+}
+
+func (ob *DatadogObserver) GetQuery(metric string, model MetricTemplateModel) (string, error) {
+	model, err := datadogModel(model)
+	if err != nil {
+		return "", err
+	}
+	return renderBuiltinQuery(ob.client, datadogQueries, metric, model)
+}
@@ -0,0 +1,211 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var datadogCredentials = map[string][]byte{
+	DatadogAPIKeySecretKey:         []byte("api-key"),
+	DatadogApplicationKeySecretKey: []byte("app-key"),
+}
+
+func TestDatadogClient_RunQuery(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			t.Errorf("Got path %s wanted %s", r.URL.Path, "/api/v1/query")
+		}
+		if key := r.Header.Get("DD-API-KEY"); key != "api-key" {
+			t.Errorf("Got API key %s wanted %s", key, "api-key")
+		}
+		if key := r.Header.Get("DD-APPLICATION-KEY"); key != "app-key" {
+			t.Errorf("Got application key %s wanted %s", key, "app-key")
+		}
+
+		from, _ := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+		to, _ := strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+		if to-from != 300 {
+			t.Errorf("Got time range %vs wanted %vs", to-from, 300)
+		}
+
+		json := `{"status":"ok","series":[{"pointlist":[[1545905245000,99.5],[1545905305000,98.5],[1545905365000,null]]}]}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	dd, err := NewDatadogClient(ts.URL, datadogCredentials, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := ForInterval(dd, "5m")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := client.RunQuery(`avg:trace.http.request.duration{kube_deployment:podinfo}`)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val != 98.5 {
+		t.Errorf("Got %v wanted %v", val, 98.5)
+	}
+}
+
+func TestDatadogClient_NoValues(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json := `{"status":"ok","series":[]}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := NewDatadogClient(ts.URL, datadogCredentials, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.RunQuery(`avg:trace.http.request.duration{kube_deployment:podinfo}`)
+	if err == nil || err.Error() != "no values found" {
+		t.Errorf("Got error %v wanted %v", err, "no values found")
+	}
+}
+
+func TestDatadogClient_IsOnline(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("DD-API-KEY") != "api-key" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["Forbidden"]}`))
+			return
+		}
+		w.Write([]byte(`{"valid":true}`))
+	}))
+	defer ts.Close()
+
+	client, err := NewDatadogClient(ts.URL, datadogCredentials, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := client.IsOnline()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !ok {
+		t.Errorf("Got %v wanted %v", ok, true)
+	}
+
+	client, err = NewDatadogClient(ts.URL, map[string][]byte{
+		DatadogAPIKeySecretKey:         []byte("invalid"),
+		DatadogApplicationKeySecretKey: []byte("app-key"),
+	}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, _ := client.IsOnline(); ok {
+		t.Errorf("Got %v wanted %v", ok, false)
+	}
+
+	if _, err := NewDatadogClient(ts.URL, nil, time.Second); err == nil {
+		t.Errorf("Got no error wanted missing credentials error")
+	}
+}
+
+func TestDatadogObserver_GetRequestSuccessRate(t *testing.T) {
+	expected := ` 100 - ( sum:trace.http.request.errors{kube_namespace:default,kube_deployment:podinfo}.as_count() / sum:trace.http.request.hits{kube_namespace:default,kube_deployment:podinfo}.as_count() ) * 100`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		if query != expected {
+			t.Errorf("\nGot %s \nWanted %s", query, expected)
+		}
+
+		json := `{"status":"ok","series":[{"pointlist":[[1545905245000,100]]}]}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := NewDatadogClient(ts.URL, datadogCredentials, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	factory := Factory{MeshProvider: "istio", Client: client}
+	observer := factory.Observer("istio")
+
+	val, err := observer.GetRequestSuccessRate(MetricTemplateModel{Name: "podinfo", Namespace: "default", Interval: "1m"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val != 100 {
+		t.Errorf("Got %v wanted %v", val, 100)
+	}
+}
+
+func TestDatadogObserver_GetRequestDuration(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json := `{"status":"ok","series":[{"pointlist":[[1545905245000,0.1]]}]}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := NewDatadogClient(ts.URL, datadogCredentials, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	observer := &DatadogObserver{
+		client: client,
+	}
+
+	val, err := observer.GetRequestDuration(MetricTemplateModel{Name: "podinfo", Namespace: "default", Interval: "1m"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val != 100*time.Millisecond {
+		t.Errorf("Got %v wanted %v", val, 100*time.Millisecond)
+	}
+}
+
+func TestDatadogObserver_GetRequestDurationPercentile(t *testing.T) {
+	expected := ` avg:trace.http.request.duration.by.service.95p{kube_namespace:default,kube_deployment:podinfo}`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		if query != expected {
+			t.Errorf("\nGot %s \nWanted %s", query, expected)
+		}
+
+		json := `{"status":"ok","series":[{"pointlist":[[1545905245000,0.1]]}]}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := NewDatadogClient(ts.URL, datadogCredentials, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	observer := &DatadogObserver{
+		client: client,
+	}
+
+	val, err := observer.GetRequestDuration(MetricTemplateModel{Name: "podinfo", Namespace: "default", Interval: "1m", Percentile: 95})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val != 100*time.Millisecond {
+		t.Errorf("Got %v wanted %v", val, 100*time.Millisecond)
+	}
+
+	if _, err := observer.GetRequestDuration(MetricTemplateModel{Name: "podinfo", Namespace: "default", Interval: "1m", Percentile: 99.9}); err == nil {
+		t.Errorf("Got no error wanted percentile not supported")
+	}
+}
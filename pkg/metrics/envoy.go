@@ -27,7 +27,7 @@ var envoyQueries = map[string]string{
 	* 100`,
 	"request-duration": `
 	histogram_quantile(
-		0.99,
+		{{ .Quantile }},
 		sum(
 			rate(
 				envoy_cluster_upstream_rq_time_bucket{
@@ -37,14 +37,37 @@ var envoyQueries = map[string]string{
 			)
 		) by (le)
 	)`,
+	"request-rate": `
+	sum(
+		rate(
+			envoy_cluster_upstream_rq{
+				kubernetes_namespace="{{ .Namespace }}",
+				kubernetes_pod_name=~"{{ .Name }}-[0-9a-zA-Z]+(-[0-9a-zA-Z]+)"
+			}[{{ .Interval }}]
+		)
+	)`,
 }
 
 type EnvoyObserver struct {
-	client *PrometheusClient
+	client Client
+}
+
+func (ob *EnvoyObserver) GetRequestSuccessRate(model MetricTemplateModel) (float64, error) {
+	query, err := ob.client.RenderQuery(model, envoyQueries["request-success-rate"])
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, err
+	}
+
+	return value, nil
 }
 
-func (ob *EnvoyObserver) GetRequestSuccessRate(name string, namespace string, interval string) (float64, error) {
-	query, err := ob.client.RenderQuery(name, namespace, interval, envoyQueries["request-success-rate"])
+func (ob *EnvoyObserver) GetRequestRate(model MetricTemplateModel) (float64, error) {
+	query, err := ob.client.RenderQuery(model, envoyQueries["request-rate"])
 	if err != nil {
 		return 0, err
 	}
@@ -57,8 +80,8 @@ func (ob *EnvoyObserver) GetRequestSuccessRate(name string, namespace string, in
 	return value, nil
 }
 
-func (ob *EnvoyObserver) GetRequestDuration(name string, namespace string, interval string) (time.Duration, error) {
-	query, err := ob.client.RenderQuery(name, namespace, interval, envoyQueries["request-duration"])
+func (ob *EnvoyObserver) GetRequestDuration(model MetricTemplateModel) (time.Duration, error) {
+	query, err := ob.client.RenderQuery(model, envoyQueries["request-duration"])
 	if err != nil {
 		return 0, err
 	}
@@ -71,3 +94,7 @@ func (ob *EnvoyObserver) GetRequestDuration(name string, namespace string, inter
 	ms := time.Duration(int64(value)) * time.Millisecond
 	return ms, nil
 }
+
+func (ob *EnvoyObserver) GetQuery(metric string, model MetricTemplateModel) (string, error) {
+	return renderBuiltinQuery(ob.client, envoyQueries, metric, model)
+}
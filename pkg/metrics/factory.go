@@ -7,11 +7,18 @@ import (
 
 type Factory struct {
 	MeshProvider string
-	Client       *PrometheusClient
+	Client       Client
 }
 
 func NewFactory(metricsServer string, meshProvider string, timeout time.Duration) (*Factory, error) {
-	client, err := NewPrometheusClient(metricsServer, timeout)
+	return NewProviderFactory(PrometheusProvider, metricsServer, nil, meshProvider, timeout)
+}
+
+// NewProviderFactory creates a factory for the metrics provider type,
+// the credentials are the data of the Secret referenced by the provider
+func NewProviderFactory(provider string, address string, credentials map[string][]byte,
+	meshProvider string, timeout time.Duration) (*Factory, error) {
+	client, err := NewClient(provider, address, credentials, ClientOptions{}, timeout)
 	if err != nil {
 		return nil, err
 	}
@@ -23,6 +30,16 @@ func NewFactory(metricsServer string, meshProvider string, timeout time.Duration
 }
 
 func (factory Factory) Observer(provider string) Interface {
+	// the observers of the providers other than Prometheus don't depend on the mesh provider
+	switch factory.Client.(type) {
+	case *DatadogClient:
+		return &DatadogObserver{
+			client: factory.Client,
+		}
+	case *InfluxDBClient:
+		return &InfluxDBObserver{}
+	}
+
 	switch {
 	case provider == "none":
 		return &HttpObserver{
@@ -27,7 +27,7 @@ var glooQueries = map[string]string{
 	* 100`,
 	"request-duration": `
 	histogram_quantile(
-		0.99,
+		{{ .Quantile }},
 		sum(
 			rate(
 				envoy_cluster_upstream_rq_time_bucket{
@@ -36,14 +36,22 @@ var glooQueries = map[string]string{
 			)
 		) by (le)
 	)`,
+	"request-rate": `
+	sum(
+		rate(
+			envoy_cluster_upstream_rq{
+				envoy_cluster_name=~"{{ .Namespace }}-{{ .Name }}-canary-[0-9a-zA-Z-]+_[0-9a-zA-Z-]+",
+			}[{{ .Interval }}]
+		)
+	)`,
 }
 
 type GlooObserver struct {
-	client *PrometheusClient
+	client Client
 }
 
-func (ob *GlooObserver) GetRequestSuccessRate(name string, namespace string, interval string) (float64, error) {
-	query, err := ob.client.RenderQuery(name, namespace, interval, glooQueries["request-success-rate"])
+func (ob *GlooObserver) GetRequestSuccessRate(model MetricTemplateModel) (float64, error) {
+	query, err := ob.client.RenderQuery(model, glooQueries["request-success-rate"])
 	if err != nil {
 		return 0, err
 	}
@@ -56,8 +64,22 @@ func (ob *GlooObserver) GetRequestSuccessRate(name string, namespace string, int
 	return value, nil
 }
 
-func (ob *GlooObserver) GetRequestDuration(name string, namespace string, interval string) (time.Duration, error) {
-	query, err := ob.client.RenderQuery(name, namespace, interval, glooQueries["request-duration"])
+func (ob *GlooObserver) GetRequestRate(model MetricTemplateModel) (float64, error) {
+	query, err := ob.client.RenderQuery(model, glooQueries["request-rate"])
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, err
+	}
+
+	return value, nil
+}
+
+func (ob *GlooObserver) GetRequestDuration(model MetricTemplateModel) (time.Duration, error) {
+	query, err := ob.client.RenderQuery(model, glooQueries["request-duration"])
 	if err != nil {
 		return 0, err
 	}
@@ -70,3 +92,7 @@ func (ob *GlooObserver) GetRequestDuration(name string, namespace string, interv
 	ms := time.Duration(int64(value)) * time.Millisecond
 	return ms, nil
 }
+
+func (ob *GlooObserver) GetQuery(metric string, model MetricTemplateModel) (string, error) {
+	return renderBuiltinQuery(ob.client, glooQueries, metric, model)
+}
@@ -30,7 +30,7 @@ func TestGlooObserver_GetRequestSuccessRate(t *testing.T) {
 		client: client,
 	}
 
-	val, err := observer.GetRequestSuccessRate("podinfo", "default", "1m")
+	val, err := observer.GetRequestSuccessRate(MetricTemplateModel{Name: "podinfo", Namespace: "default", Interval: "1m"})
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -40,6 +40,39 @@ func TestGlooObserver_GetRequestSuccessRate(t *testing.T) {
 	}
 }
 
+func TestGlooObserver_GetRequestRate(t *testing.T) {
+	expected := ` sum( rate( envoy_cluster_upstream_rq{ envoy_cluster_name=~"default-podinfo-canary-[0-9a-zA-Z-]+_[0-9a-zA-Z-]+", }[1m] ) )`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		promql := r.URL.Query()["query"][0]
+		if promql != expected {
+			t.Errorf("\nGot %s \nWanted %s", promql, expected)
+		}
+
+		json := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"25.5"]}]}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := NewPrometheusClient(ts.URL, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	observer := &GlooObserver{
+		client: client,
+	}
+
+	val, err := observer.GetRequestRate(MetricTemplateModel{Name: "podinfo", Namespace: "default", Interval: "1m"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val != 25.5 {
+		t.Errorf("Got %v wanted %v", val, 25.5)
+	}
+}
+
 func TestGlooObserver_GetRequestDuration(t *testing.T) {
 	expected := ` histogram_quantile( 0.99, sum( rate( envoy_cluster_upstream_rq_time_bucket{ envoy_cluster_name=~"default-podinfo-canary-[0-9a-zA-Z-]+_[0-9a-zA-Z-]+", }[1m] ) ) by (le) )`
 
@@ -63,7 +96,7 @@ func TestGlooObserver_GetRequestDuration(t *testing.T) {
 		client: client,
 	}
 
-	val, err := observer.GetRequestDuration("podinfo", "default", "1m")
+	val, err := observer.GetRequestDuration(MetricTemplateModel{Name: "podinfo", Namespace: "default", Interval: "1m"})
 	if err != nil {
 		t.Fatal(err.Error())
 	}
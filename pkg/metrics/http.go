@@ -25,7 +25,7 @@ var httpQueries = map[string]string{
 	* 100`,
 	"request-duration": `
 	histogram_quantile(
-		0.99,
+		{{ .Quantile }},
 		sum(
 			rate(
 				http_request_duration_seconds_bucket{
@@ -35,14 +35,37 @@ var httpQueries = map[string]string{
 			)
 		) by (le)
 	)`,
+	"request-rate": `
+	sum(
+		rate(
+			http_request_duration_seconds_count{
+				kubernetes_namespace="{{ .Namespace }}",
+				kubernetes_pod_name=~"{{ .Name }}-[0-9a-zA-Z]+(-[0-9a-zA-Z]+)"
+			}[{{ .Interval }}]
+		)
+	)`,
 }
 
 type HttpObserver struct {
-	client *PrometheusClient
+	client Client
+}
+
+func (ob *HttpObserver) GetRequestSuccessRate(model MetricTemplateModel) (float64, error) {
+	query, err := ob.client.RenderQuery(model, httpQueries["request-success-rate"])
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, err
+	}
+
+	return value, nil
 }
 
-func (ob *HttpObserver) GetRequestSuccessRate(name string, namespace string, interval string) (float64, error) {
-	query, err := ob.client.RenderQuery(name, namespace, interval, httpQueries["request-success-rate"])
+func (ob *HttpObserver) GetRequestRate(model MetricTemplateModel) (float64, error) {
+	query, err := ob.client.RenderQuery(model, httpQueries["request-rate"])
 	if err != nil {
 		return 0, err
 	}
@@ -55,8 +78,8 @@ func (ob *HttpObserver) GetRequestSuccessRate(name string, namespace string, int
 	return value, nil
 }
 
-func (ob *HttpObserver) GetRequestDuration(name string, namespace string, interval string) (time.Duration, error) {
-	query, err := ob.client.RenderQuery(name, namespace, interval, httpQueries["request-duration"])
+func (ob *HttpObserver) GetRequestDuration(model MetricTemplateModel) (time.Duration, error) {
+	query, err := ob.client.RenderQuery(model, httpQueries["request-duration"])
 	if err != nil {
 		return 0, err
 	}
@@ -69,3 +92,7 @@ func (ob *HttpObserver) GetRequestDuration(name string, namespace string, interv
 	ms := time.Duration(int64(value*1000)) * time.Millisecond
 	return ms, nil
 }
+
+func (ob *HttpObserver) GetQuery(metric string, model MetricTemplateModel) (string, error) {
+	return renderBuiltinQuery(ob.client, httpQueries, metric, model)
+}
@@ -0,0 +1,286 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// InfluxDBTokenSecretKey is the Secret key that holds the InfluxDB authentication token
+	InfluxDBTokenSecretKey = "influxdb_token"
+	// InfluxDBUsernameSecretKey is the Secret key that holds the InfluxDB 1.x user name
+	InfluxDBUsernameSecretKey = "influxdb_username"
+	// InfluxDBPasswordSecretKey is the Secret key that holds the InfluxDB 1.x password
+	InfluxDBPasswordSecretKey = "influxdb_password"
+)
+
+// InfluxDBClient is executing Flux and InfluxQL queries,
+// the query parameters of the address (db, rp, org) are sent with every query
+type InfluxDBClient struct {
+	timeout  time.Duration
+	url      url.URL
+	token    string
+	username string
+	password string
+}
+
+type influxQLResponse struct {
+	Results []struct {
+		Error  string `json:"error"`
+		Series []struct {
+			Values [][]interface{} `json:"values"`
+		} `json:"series"`
+	} `json:"results"`
+}
+
+// NewInfluxDBClient creates an InfluxDB client for the provided address,
+// the token or the user name and password are read from the provider credentials
+func NewInfluxDBClient(address string, credentials map[string][]byte, timeout time.Duration) (*InfluxDBClient, error) {
+	if address == "" {
+		return nil, fmt.Errorf("influxdb address is required")
+	}
+	influxURL, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InfluxDBClient{
+		timeout:  timeout,
+		url:      *influxURL,
+		token:    string(credentials[InfluxDBTokenSecretKey]),
+		username: string(credentials[InfluxDBUsernameSecretKey]),
+		password: string(credentials[InfluxDBPasswordSecretKey]),
+	}, nil
+}
+
+// RenderQuery renders the InfluxDB query using the provided text template
+func (i *InfluxDBClient) RenderQuery(model MetricTemplateModel, tmpl string) (string, error) {
+	return renderTemplate(tmpl, model)
+}
+
+// RunQuery executes the query and returns the last value of the result,
+// queries that contain the pipe-forward operator are run as Flux, the others as InfluxQL
+func (i *InfluxDBClient) RunQuery(query string) (float64, error) {
+	var values []float64
+	var err error
+	if isFluxQuery(query) {
+		values, err = i.runFlux(query)
+	} else {
+		values, err = i.runInfluxQL(query)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return values[len(values)-1], nil
+}
+
+// RunRangeQuery is not supported, the Flux and InfluxQL queries specify their own time range
+func (i *InfluxDBClient) RunRangeQuery(query string, start time.Time, end time.Time, step time.Duration) ([]float64, error) {
+	return nil, fmt.Errorf("range queries are not supported by the influxdb provider")
+}
+
+func isFluxQuery(query string) bool {
+	return strings.Contains(query, "|>")
+}
+
+// runFlux runs the query with the InfluxDB 2.x API and parses the _value column of the CSV tables
+func (i *InfluxDBClient) runFlux(query string) ([]float64, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"query": query,
+		"type":  "flux",
+		"dialect": map[string]interface{}{
+			"header":      true,
+			"annotations": []string{},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := i.do("POST", "./api/v2/query", url.Values{}, bytes.NewReader(body), "application/csv")
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(b))
+	reader.FieldsPerRecord = -1
+	var values []float64
+	valueColumn := -1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing result: %s, '%s'", err.Error(), string(b))
+		}
+
+		// every table starts with a header row
+		if index := indexOf(record, "_value"); index >= 0 {
+			valueColumn = index
+			continue
+		}
+		if valueColumn < 0 || valueColumn >= len(record) {
+			continue
+		}
+
+		f, err := strconv.ParseFloat(record[valueColumn], 64)
+		if err != nil || math.IsNaN(f) {
+			continue
+		}
+		values = append(values, f)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no values found")
+	}
+
+	return values, nil
+}
+
+// runInfluxQL runs the query with the InfluxDB 1.x API and parses the last column of the series
+func (i *InfluxDBClient) runInfluxQL(query string) ([]float64, error) {
+	params := url.Values{}
+	params.Set("q", trimQuery(query))
+	if i.username != "" {
+		params.Set("u", i.username)
+		params.Set("p", i.password)
+	}
+
+	b, err := i.do("GET", "./query", params, nil, "application/json")
+	if err != nil {
+		return nil, err
+	}
+
+	var result influxQLResponse
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, fmt.Errorf("error unmarshaling result: %s, '%s'", err.Error(), string(b))
+	}
+
+	var values []float64
+	for _, r := range result.Results {
+		if r.Error != "" {
+			return nil, fmt.Errorf("error response: %s", r.Error)
+		}
+		for _, series := range r.Series {
+			for _, row := range series.Values {
+				// rows start with the time column and the value is null when there is no data
+				if len(row) < 2 {
+					continue
+				}
+				if f, ok := row[len(row)-1].(float64); ok {
+					values = append(values, f)
+				}
+			}
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no values found")
+	}
+
+	return values, nil
+}
+
+// IsOnline calls the InfluxDB ping endpoint and returns an error if the API is unreachable
+func (i *InfluxDBClient) IsOnline() (bool, error) {
+	if _, err := i.do("GET", "./ping", url.Values{}, nil, ""); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetMetricsServer returns the InfluxDB address
+func (i *InfluxDBClient) GetMetricsServer() string {
+	return i.url.String()
+}
+
+// do calls the InfluxDB API with the client credentials and returns the response body
+func (i *InfluxDBClient) do(method string, api string, params url.Values, body io.Reader, accept string) ([]byte, error) {
+	u, err := url.Parse(api)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(i.url.Path, u.Path)
+
+	// the address query parameters select the database or the organization
+	for k, v := range i.url.Query() {
+		params[k] = v
+	}
+	u.RawQuery = params.Encode()
+
+	u = i.url.ResolveReference(u)
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if i.token != "" {
+		req.Header.Set("Authorization", "Token "+i.token)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), i.timeout)
+	defer cancel()
+
+	r, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading body: %s", err.Error())
+	}
+
+	if 400 <= r.StatusCode {
+		return nil, fmt.Errorf("error response: %s", string(b))
+	}
+
+	return b, nil
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// InfluxDBObserver rejects the builtin checks, the measurements depend on how the metrics are collected
+type InfluxDBObserver struct{}
+
+func (ob *InfluxDBObserver) GetRequestSuccessRate(model MetricTemplateModel) (float64, error) {
+	return 0, fmt.Errorf("builtin metrics are not supported by the influxdb provider")
+}
+
+func (ob *InfluxDBObserver) GetRequestRate(model MetricTemplateModel) (float64, error) {
+	return 0, fmt.Errorf("builtin metrics are not supported by the influxdb provider")
+}
+
+func (ob *InfluxDBObserver) GetRequestDuration(model MetricTemplateModel) (time.Duration, error) {
+	return 0, fmt.Errorf("builtin metrics are not supported by the influxdb provider")
+}
+
+func (ob *InfluxDBObserver) GetQuery(metric string, model MetricTemplateModel) (string, error) {
+	return "", fmt.Errorf("builtin metrics are not supported by the influxdb provider")
+}
@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestInfluxDBClient_RunFluxQuery(t *testing.T) {
+	query := `from(bucket: "metrics")
+  |> range(start: -1m)
+  |> filter(fn: (r) => r._measurement == "http_errors" and r.deployment == "podinfo")
+  |> sum()`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/api/v2/query" {
+			t.Errorf("Got %s %s wanted %s %s", r.Method, r.URL.Path, "POST", "/api/v2/query")
+		}
+		if org := r.URL.Query().Get("org"); org != "flagger" {
+			t.Errorf("Got org %s wanted %s", org, "flagger")
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Token secret" {
+			t.Errorf("Got authorization %s wanted %s", auth, "Token secret")
+		}
+
+		b, _ := ioutil.ReadAll(r.Body)
+		var body map[string]interface{}
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Fatal(err)
+		}
+		if body["query"] != query || body["type"] != "flux" {
+			t.Errorf("Got body %s wanted the flux query", string(b))
+		}
+
+		csv := ",result,table,_start,_stop,_value,deployment\r\n" +
+			",_result,0,2020-01-10T10:00:00Z,2020-01-10T10:01:00Z,3,podinfo\r\n" +
+			"\r\n" +
+			",result,table,_start,_stop,_value,deployment\r\n" +
+			",_result,1,2020-01-10T10:00:00Z,2020-01-10T10:01:00Z,5,podinfo\r\n"
+		w.Write([]byte(csv))
+	}))
+	defer ts.Close()
+
+	client, err := NewInfluxDBClient(ts.URL+"?org=flagger", map[string][]byte{InfluxDBTokenSecretKey: []byte("secret")}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := client.RunQuery(query)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val != 5 {
+		t.Errorf("Got %v wanted %v", val, 5)
+	}
+}
+
+func TestInfluxDBClient_RunInfluxQLQuery(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/query" {
+			t.Errorf("Got %s %s wanted %s %s", r.Method, r.URL.Path, "GET", "/query")
+		}
+		params := r.URL.Query()
+		if params.Get("db") != "telegraf" || params.Get("u") != "flagger" || params.Get("p") != "secret" {
+			t.Errorf("Got params %v wanted db, u and p", params)
+		}
+		if q := params.Get("q"); q != `SELECT mean("value") FROM "latency" WHERE time > now() - 1m` {
+			t.Errorf("Got query %s", q)
+		}
+
+		json := `{"results":[{"statement_id":0,"series":[{"name":"latency","columns":["time","mean"],"values":[["2020-01-10T10:00:00Z",120.5],["2020-01-10T10:01:00Z",null]]}]}]}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := NewInfluxDBClient(ts.URL+"?db=telegraf", map[string][]byte{
+		InfluxDBUsernameSecretKey: []byte("flagger"),
+		InfluxDBPasswordSecretKey: []byte("secret"),
+	}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := client.RunQuery(`SELECT mean("value")
+	FROM "latency"
+	WHERE time > now() - 1m`)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val != 120.5 {
+		t.Errorf("Got %v wanted %v", val, 120.5)
+	}
+}
+
+func TestInfluxDBClient_NoValues(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v2/query" {
+			w.Write([]byte("\r\n"))
+			return
+		}
+		w.Write([]byte(`{"results":[{"statement_id":0}]}`))
+	}))
+	defer ts.Close()
+
+	client, err := NewInfluxDBClient(ts.URL, nil, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, query := range []string{`from(bucket: "metrics") |> range(start: -1m)`, `SELECT mean("value") FROM "latency"`} {
+		_, err = client.RunQuery(query)
+		if err == nil || err.Error() != "no values found" {
+			t.Errorf("Got error %v wanted %v for %s", err, "no values found", query)
+		}
+	}
+}
+
+func TestInfluxDBClient_QueryError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results":[{"statement_id":0,"error":"database not found: telegraf"}]}`))
+	}))
+	defer ts.Close()
+
+	client, err := NewInfluxDBClient(ts.URL+"?db=telegraf", nil, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.RunQuery(`SELECT mean("value") FROM "latency"`); err == nil {
+		t.Errorf("Got no error wanted database not found")
+	}
+
+	if _, err := NewInfluxDBClient("", nil, time.Second); err == nil {
+		t.Errorf("Got no error wanted address required")
+	}
+}
@@ -29,7 +29,7 @@ var istioQueries = map[string]string{
 	* 100`,
 	"request-duration": `
 	histogram_quantile(
-		0.99,
+		{{ .Quantile }},
 		sum(
 			rate(
 				istio_request_duration_seconds_bucket{
@@ -40,14 +40,24 @@ var istioQueries = map[string]string{
 			)
 		) by (le)
 	)`,
+	"request-rate": `
+	sum(
+		rate(
+			istio_requests_total{
+				reporter="destination",
+				destination_workload_namespace="{{ .Namespace }}",
+				destination_workload=~"{{ .Name }}"
+			}[{{ .Interval }}]
+		)
+	)`,
 }
 
 type IstioObserver struct {
-	client *PrometheusClient
+	client Client
 }
 
-func (ob *IstioObserver) GetRequestSuccessRate(name string, namespace string, interval string) (float64, error) {
-	query, err := ob.client.RenderQuery(name, namespace, interval, istioQueries["request-success-rate"])
+func (ob *IstioObserver) GetRequestSuccessRate(model MetricTemplateModel) (float64, error) {
+	query, err := ob.client.RenderQuery(model, istioQueries["request-success-rate"])
 	if err != nil {
 		return 0, err
 	}
@@ -60,8 +70,22 @@ func (ob *IstioObserver) GetRequestSuccessRate(name string, namespace string, in
 	return value, nil
 }
 
-func (ob *IstioObserver) GetRequestDuration(name string, namespace string, interval string) (time.Duration, error) {
-	query, err := ob.client.RenderQuery(name, namespace, interval, istioQueries["request-duration"])
+func (ob *IstioObserver) GetRequestRate(model MetricTemplateModel) (float64, error) {
+	query, err := ob.client.RenderQuery(model, istioQueries["request-rate"])
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, err
+	}
+
+	return value, nil
+}
+
+func (ob *IstioObserver) GetRequestDuration(model MetricTemplateModel) (time.Duration, error) {
+	query, err := ob.client.RenderQuery(model, istioQueries["request-duration"])
 	if err != nil {
 		return 0, err
 	}
@@ -74,3 +98,7 @@ func (ob *IstioObserver) GetRequestDuration(name string, namespace string, inter
 	ms := time.Duration(int64(value*1000)) * time.Millisecond
 	return ms, nil
 }
+
+func (ob *IstioObserver) GetQuery(metric string, model MetricTemplateModel) (string, error) {
+	return renderBuiltinQuery(ob.client, istioQueries, metric, model)
+}
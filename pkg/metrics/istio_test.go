@@ -30,7 +30,7 @@ func TestIstioObserver_GetRequestSuccessRate(t *testing.T) {
 		client: client,
 	}
 
-	val, err := observer.GetRequestSuccessRate("podinfo", "default", "1m")
+	val, err := observer.GetRequestSuccessRate(MetricTemplateModel{Name: "podinfo", Namespace: "default", Interval: "1m"})
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -40,6 +40,39 @@ func TestIstioObserver_GetRequestSuccessRate(t *testing.T) {
 	}
 }
 
+func TestIstioObserver_GetRequestRate(t *testing.T) {
+	expected := ` sum( rate( istio_requests_total{ reporter="destination", destination_workload_namespace="default", destination_workload=~"podinfo" }[1m] ) )`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		promql := r.URL.Query()["query"][0]
+		if promql != expected {
+			t.Errorf("\nGot %s \nWanted %s", promql, expected)
+		}
+
+		json := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"25.5"]}]}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := NewPrometheusClient(ts.URL, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	observer := &IstioObserver{
+		client: client,
+	}
+
+	val, err := observer.GetRequestRate(MetricTemplateModel{Name: "podinfo", Namespace: "default", Interval: "1m"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val != 25.5 {
+		t.Errorf("Got %v wanted %v", val, 25.5)
+	}
+}
+
 func TestIstioObserver_GetRequestDuration(t *testing.T) {
 	expected := ` histogram_quantile( 0.99, sum( rate( istio_request_duration_seconds_bucket{ reporter="destination", destination_workload_namespace="default", destination_workload=~"podinfo" }[1m] ) ) by (le) )`
 
@@ -63,7 +96,7 @@ func TestIstioObserver_GetRequestDuration(t *testing.T) {
 		client: client,
 	}
 
-	val, err := observer.GetRequestDuration("podinfo", "default", "1m")
+	val, err := observer.GetRequestDuration(MetricTemplateModel{Name: "podinfo", Namespace: "default", Interval: "1m"})
 	if err != nil {
 		t.Fatal(err.Error())
 	}
@@ -72,3 +105,36 @@ func TestIstioObserver_GetRequestDuration(t *testing.T) {
 		t.Errorf("Got %v wanted %v", val, 100*time.Millisecond)
 	}
 }
+
+func TestIstioObserver_GetRequestDurationPercentile(t *testing.T) {
+	expected := ` histogram_quantile( 0.999, sum( rate( istio_request_duration_seconds_bucket{ reporter="destination", destination_workload_namespace="default", destination_workload=~"podinfo" }[1m] ) ) by (le) )`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		promql := r.URL.Query()["query"][0]
+		if promql != expected {
+			t.Errorf("\nGot %s \nWanted %s", promql, expected)
+		}
+
+		json := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"0.250"]}]}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := NewPrometheusClient(ts.URL, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	observer := &IstioObserver{
+		client: client,
+	}
+
+	val, err := observer.GetRequestDuration(MetricTemplateModel{Name: "podinfo", Namespace: "default", Interval: "1m", Percentile: 99.9})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val != 250*time.Millisecond {
+		t.Errorf("Got %v wanted %v", val, 250*time.Millisecond)
+	}
+}
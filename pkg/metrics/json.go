@@ -0,0 +1,193 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// JSONHeaderSecretKeyPrefix is the prefix of the Secret keys that hold the request headers,
+// the header name is the rest of the key
+const JSONHeaderSecretKeyPrefix = "header_"
+
+// JSONClient is executing HTTP requests and extracts the metric value from the JSON response,
+// GET queries are appended to the address and POST queries are sent as the request body
+type JSONClient struct {
+	timeout  time.Duration
+	url      url.URL
+	method   string
+	jsonPath *jsonpath.JSONPath
+	headers  map[string]string
+}
+
+// NewJSONClient creates a JSON client for the provided API address,
+// the headers are read from the prefixed keys of the Secret referenced by the provider
+func NewJSONClient(address string, credentials map[string][]byte, options ClientOptions, timeout time.Duration) (*JSONClient, error) {
+	if address == "" {
+		return nil, fmt.Errorf("json address is required")
+	}
+	jsonURL, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+
+	method := options.Method
+	switch method {
+	case "":
+		method = http.MethodGet
+	case http.MethodGet, http.MethodPost:
+	default:
+		return nil, fmt.Errorf("json method %s not supported", method)
+	}
+
+	jsonPath := options.JSONPath
+	if jsonPath == "" {
+		return nil, fmt.Errorf("json path is required")
+	}
+	// accept the JSONPath expressions with or without the kubectl braces
+	if !strings.HasPrefix(jsonPath, "{") {
+		jsonPath = fmt.Sprintf("{%s}", jsonPath)
+	}
+	jp := jsonpath.New("metric")
+	if err := jp.Parse(jsonPath); err != nil {
+		return nil, fmt.Errorf("json path %s parse error %v", jsonPath, err)
+	}
+
+	headers := make(map[string]string)
+	for k, v := range credentials {
+		if name := strings.TrimPrefix(k, JSONHeaderSecretKeyPrefix); name != k && name != "" {
+			headers[name] = string(v)
+		}
+	}
+
+	return &JSONClient{
+		timeout:  timeout,
+		url:      *jsonURL,
+		method:   method,
+		jsonPath: jp,
+		headers:  headers,
+	}, nil
+}
+
+// RenderQuery renders the request using the provided text template
+func (j *JSONClient) RenderQuery(model MetricTemplateModel, tmpl string) (string, error) {
+	return renderTemplate(tmpl, model)
+}
+
+// RunQuery sends the request and converts the value found at the JSONPath to float64
+func (j *JSONClient) RunQuery(query string) (float64, error) {
+	u := j.url
+	var body io.Reader
+	if j.method == http.MethodGet {
+		ref, err := url.Parse(strings.TrimSpace(query))
+		if err != nil {
+			return 0, err
+		}
+		u.Path = strings.TrimSuffix(u.Path, "/") + ref.Path
+		if ref.RawQuery != "" {
+			u.RawQuery = ref.RawQuery
+		}
+	} else {
+		body = strings.NewReader(query)
+	}
+
+	b, err := j.do(j.method, u.String(), body)
+	if err != nil {
+		return 0, err
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return 0, fmt.Errorf("error unmarshaling result: %s, '%s'", err.Error(), string(b))
+	}
+
+	results, err := j.jsonPath.FindResults(data)
+	if err != nil {
+		// the path doesn't exist in the response
+		return 0, fmt.Errorf("no values found")
+	}
+
+	var value *float64
+	for _, result := range results {
+		for _, v := range result {
+			if !v.IsValid() || !v.CanInterface() {
+				continue
+			}
+			switch val := v.Interface().(type) {
+			case float64:
+				value = &val
+			case string:
+				if f, err := strconv.ParseFloat(val, 64); err == nil {
+					value = &f
+				}
+			}
+		}
+	}
+	if value == nil {
+		return 0, fmt.Errorf("no values found")
+	}
+
+	return *value, nil
+}
+
+// RunRangeQuery is not supported, the JSON APIs return a single value
+func (j *JSONClient) RunRangeQuery(query string, start time.Time, end time.Time, step time.Duration) ([]float64, error) {
+	return nil, fmt.Errorf("range queries are not supported by the json provider")
+}
+
+// IsOnline calls the API address and returns an error if the API is unreachable
+func (j *JSONClient) IsOnline() (bool, error) {
+	if _, err := j.do(http.MethodGet, j.url.String(), nil); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetMetricsServer returns the API address
+func (j *JSONClient) GetMetricsServer() string {
+	return j.url.String()
+}
+
+// do sends the request with the client headers and returns the response body
+func (j *JSONClient) do(method string, address string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequest(method, address, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range j.headers {
+		req.Header.Set(k, v)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), j.timeout)
+	defer cancel()
+
+	r, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading body: %s", err.Error())
+	}
+
+	if 400 <= r.StatusCode {
+		return nil, fmt.Errorf("error response: %s", string(b))
+	}
+
+	return b, nil
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestJSONClient_RunQueryGet(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/api/health/podinfo" {
+			t.Errorf("Got %s %s wanted %s %s", r.Method, r.URL.Path, "GET", "/api/health/podinfo")
+		}
+		if window := r.URL.Query().Get("window"); window != "1m" {
+			t.Errorf("Got window %s wanted %s", window, "1m")
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer token" {
+			t.Errorf("Got authorization %s wanted %s", auth, "Bearer token")
+		}
+		// only the prefixed secret keys are sent as headers
+		if password := r.Header.Get("password"); password != "" {
+			t.Errorf("Got password header %s wanted none", password)
+		}
+		w.Write([]byte(`{"service":"podinfo","health":{"score":97.5}}`))
+	}))
+	defer ts.Close()
+
+	credentials := map[string][]byte{
+		JSONHeaderSecretKeyPrefix + "Authorization": []byte("Bearer token"),
+		"password": []byte("secret"),
+	}
+	client, err := NewJSONClient(ts.URL+"/api", credentials, ClientOptions{JSONPath: "$.health.score"}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query, err := client.RenderQuery(MetricTemplateModel{Name: "podinfo", Namespace: "default", Interval: "1m"}, "/health/{{ .Name }}?window={{ .Interval }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := client.RunQuery(query)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val != 97.5 {
+		t.Errorf("Got %v wanted %v", val, 97.5)
+	}
+}
+
+func TestJSONClient_RunQueryPost(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("Got method %s wanted %s", r.Method, "POST")
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		if string(b) != `{"service":"podinfo","namespace":"test"}` {
+			t.Errorf("Got body %s", string(b))
+		}
+		w.Write([]byte(`{"items":[{"name":"errors","value":"3"}]}`))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(JSONProvider, ts.URL, nil, ClientOptions{Method: "POST", JSONPath: "{.items[0].value}"}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := client.RunQuery(`{"service":"podinfo","namespace":"test"}`)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if val != 3 {
+		t.Errorf("Got %v wanted %v", val, 3)
+	}
+}
+
+func TestJSONClient_NoValues(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"health":{"status":"unknown"}}`))
+	}))
+	defer ts.Close()
+
+	for _, path := range []string{".health.score", ".health.status"} {
+		client, err := NewJSONClient(ts.URL, nil, ClientOptions{Method: "GET", JSONPath: path}, time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = client.RunQuery("/")
+		if err == nil || err.Error() != "no values found" {
+			t.Errorf("Got error %v wanted %v for %s", err, "no values found", path)
+		}
+	}
+
+	if _, err := NewJSONClient(ts.URL, nil, ClientOptions{Method: "PUT", JSONPath: ".health.score"}, time.Second); err == nil {
+		t.Errorf("Got no error wanted method not supported")
+	}
+	if _, err := NewJSONClient(ts.URL, nil, ClientOptions{Method: "GET"}, time.Second); err == nil {
+		t.Errorf("Got no error wanted json path required")
+	}
+}
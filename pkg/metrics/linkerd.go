@@ -29,7 +29,7 @@ var linkerdQueries = map[string]string{
 	* 100`,
 	"request-duration": `
 	histogram_quantile(
-		0.99,
+		{{ .Quantile }},
 		sum(
 			rate(
 				response_latency_ms_bucket{
@@ -40,14 +40,24 @@ var linkerdQueries = map[string]string{
 			)
 		) by (le)
 	)`,
+	"request-rate": `
+	sum(
+		rate(
+			response_total{
+				namespace="{{ .Namespace }}",
+				deployment=~"{{ .Name }}",
+				direction="inbound"
+			}[{{ .Interval }}]
+		)
+	)`,
 }
 
 type LinkerdObserver struct {
-	client *PrometheusClient
+	client Client
 }
 
-func (ob *LinkerdObserver) GetRequestSuccessRate(name string, namespace string, interval string) (float64, error) {
-	query, err := ob.client.RenderQuery(name, namespace, interval, linkerdQueries["request-success-rate"])
+func (ob *LinkerdObserver) GetRequestSuccessRate(model MetricTemplateModel) (float64, error) {
+	query, err := ob.client.RenderQuery(model, linkerdQueries["request-success-rate"])
 	if err != nil {
 		return 0, err
 	}
@@ -60,8 +70,22 @@ func (ob *LinkerdObserver) GetRequestSuccessRate(name string, namespace string,
 	return value, nil
 }
 
-func (ob *LinkerdObserver) GetRequestDuration(name string, namespace string, interval string) (time.Duration, error) {
-	query, err := ob.client.RenderQuery(name, namespace, interval, linkerdQueries["request-duration"])
+func (ob *LinkerdObserver) GetRequestRate(model MetricTemplateModel) (float64, error) {
+	query, err := ob.client.RenderQuery(model, linkerdQueries["request-rate"])
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, err
+	}
+
+	return value, nil
+}
+
+func (ob *LinkerdObserver) GetRequestDuration(model MetricTemplateModel) (time.Duration, error) {
+	query, err := ob.client.RenderQuery(model, linkerdQueries["request-duration"])
 	if err != nil {
 		return 0, err
 	}
@@ -74,3 +98,7 @@ func (ob *LinkerdObserver) GetRequestDuration(name string, namespace string, int
 	ms := time.Duration(int64(value)) * time.Millisecond
 	return ms, nil
 }
+
+func (ob *LinkerdObserver) GetQuery(metric string, model MetricTemplateModel) (string, error) {
+	return renderBuiltinQuery(ob.client, linkerdQueries, metric, model)
+}
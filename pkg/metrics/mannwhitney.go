@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MannWhitneyU runs the Mann-Whitney U test on two independent samples, it returns the U statistic of x
+// and the two-sided p-value computed with the normal approximation corrected for ties and continuity
+func MannWhitneyU(x []float64, y []float64) (float64, float64, error) {
+	n1, n2 := len(x), len(y)
+	if n1 == 0 || n2 == 0 {
+		return 0, 0, fmt.Errorf("samples must not be empty")
+	}
+
+	type sample struct {
+		value float64
+		first bool
+	}
+	samples := make([]sample, 0, n1+n2)
+	for _, v := range x {
+		samples = append(samples, sample{value: v, first: true})
+	}
+	for _, v := range y {
+		samples = append(samples, sample{value: v})
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].value < samples[j].value })
+
+	// tied values get the average of their ranks
+	n := len(samples)
+	rankSum, ties := 0.0, 0.0
+	for i := 0; i < n; {
+		j := i
+		for j < n && samples[j].value == samples[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if samples[k].first {
+				rankSum += rank
+			}
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+
+	u := rankSum - float64(n1*(n1+1))/2
+	mean := float64(n1*n2) / 2
+	variance := float64(n1*n2) / 12 * (float64(n+1) - ties/float64(n*(n-1)))
+	if n < 2 || variance <= 0 {
+		// all the values are equal
+		return u, 1, nil
+	}
+
+	diff := math.Abs(u-mean) - 0.5
+	if diff < 0 {
+		diff = 0
+	}
+	z := diff / math.Sqrt(variance)
+	return u, math.Erfc(z / math.Sqrt2), nil
+}
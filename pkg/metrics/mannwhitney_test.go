@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMannWhitneyU(t *testing.T) {
+	x := []float64{19, 22, 16, 29, 24}
+	y := []float64{20, 11, 17, 12}
+
+	u, p, err := MannWhitneyU(x, y)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if u != 17 {
+		t.Errorf("Got U %v wanted %v", u, 17)
+	}
+
+	// normal approximation with continuity correction
+	if math.Abs(p-0.1113) > 0.001 {
+		t.Errorf("Got p-value %v wanted %v", p, 0.1113)
+	}
+}
+
+func TestMannWhitneyU_Shifted(t *testing.T) {
+	var x, y []float64
+	for i := 0; i < 20; i++ {
+		x = append(x, 100+float64(i%5))
+		y = append(y, 200+float64(i%5))
+	}
+
+	u, p, err := MannWhitneyU(x, y)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if u != 0 {
+		t.Errorf("Got U %v wanted %v", u, 0)
+	}
+
+	if p > 0.001 {
+		t.Errorf("Got p-value %v wanted less than %v", p, 0.001)
+	}
+}
+
+func TestMannWhitneyU_Equal(t *testing.T) {
+	x := []float64{1, 1, 1}
+	y := []float64{1, 1}
+
+	_, p, err := MannWhitneyU(x, y)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if p != 1 {
+		t.Errorf("Got p-value %v wanted %v", p, 1)
+	}
+
+	if _, _, err := MannWhitneyU(x, nil); err == nil {
+		t.Errorf("Got no error wanted empty samples error")
+	}
+}
@@ -25,7 +25,22 @@ var nginxQueries = map[string]string{
 		)
 	) 
 	* 100`,
+	// the average upstream latency is used unless a percentile is specified
 	"request-duration": `
+	{{- if .Percentile }}
+	histogram_quantile(
+		{{ .Quantile }},
+		sum(
+			rate(
+				nginx_ingress_controller_request_duration_seconds_bucket{
+					namespace="{{ .Namespace }}",
+					ingress="{{ .Name }}"
+				}[{{ .Interval }}]
+			)
+		) by (le)
+	) 
+	* 1000
+	{{- else }}
 	sum(
 		rate(
 			nginx_ingress_controller_ingress_upstream_latency_seconds_sum{
@@ -43,15 +58,39 @@ var nginxQueries = map[string]string{
 			}[{{ .Interval }}]
 		)
 	) 
-	* 1000`,
+	* 1000
+	{{- end }}`,
+	"request-rate": `
+	sum(
+		rate(
+			nginx_ingress_controller_requests{
+				namespace="{{ .Namespace }}",
+				ingress="{{ .Name }}"
+			}[{{ .Interval }}]
+		)
+	)`,
 }
 
 type NginxObserver struct {
-	client *PrometheusClient
+	client Client
+}
+
+func (ob *NginxObserver) GetRequestSuccessRate(model MetricTemplateModel) (float64, error) {
+	query, err := ob.client.RenderQuery(model, nginxQueries["request-success-rate"])
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, err
+	}
+
+	return value, nil
 }
 
-func (ob *NginxObserver) GetRequestSuccessRate(name string, namespace string, interval string) (float64, error) {
-	query, err := ob.client.RenderQuery(name, namespace, interval, nginxQueries["request-success-rate"])
+func (ob *NginxObserver) GetRequestRate(model MetricTemplateModel) (float64, error) {
+	query, err := ob.client.RenderQuery(model, nginxQueries["request-rate"])
 	if err != nil {
 		return 0, err
 	}
@@ -64,8 +103,8 @@ func (ob *NginxObserver) GetRequestSuccessRate(name string, namespace string, in
 	return value, nil
 }
 
-func (ob *NginxObserver) GetRequestDuration(name string, namespace string, interval string) (time.Duration, error) {
-	query, err := ob.client.RenderQuery(name, namespace, interval, nginxQueries["request-duration"])
+func (ob *NginxObserver) GetRequestDuration(model MetricTemplateModel) (time.Duration, error) {
+	query, err := ob.client.RenderQuery(model, nginxQueries["request-duration"])
 	if err != nil {
 		return 0, err
 	}
@@ -78,3 +117,7 @@ func (ob *NginxObserver) GetRequestDuration(name string, namespace string, inter
 	ms := time.Duration(int64(value)) * time.Millisecond
 	return ms, nil
 }
+
+func (ob *NginxObserver) GetQuery(metric string, model MetricTemplateModel) (string, error) {
+	return renderBuiltinQuery(ob.client, nginxQueries, metric, model)
+}
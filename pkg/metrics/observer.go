@@ -1,10 +1,23 @@
 package metrics
 
 import (
+	"fmt"
 	"time"
 )
 
 type Interface interface {
-	GetRequestSuccessRate(name string, namespace string, interval string) (float64, error)
-	GetRequestDuration(name string, namespace string, interval string) (time.Duration, error)
+	GetRequestSuccessRate(model MetricTemplateModel) (float64, error)
+	GetRequestRate(model MetricTemplateModel) (float64, error)
+	GetRequestDuration(model MetricTemplateModel) (time.Duration, error)
+	// GetQuery renders the query of a builtin metric for the workload of the model
+	GetQuery(metric string, model MetricTemplateModel) (string, error)
+}
+
+// renderBuiltinQuery renders the query of a builtin metric using the provider queries
+func renderBuiltinQuery(client Client, queries map[string]string, metric string, model MetricTemplateModel) (string, error) {
+	tmpl, ok := queries[metric]
+	if !ok {
+		return "", fmt.Errorf("builtin metric %s not found", metric)
+	}
+	return client.RenderQuery(model, tmpl)
 }
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// PrometheusProvider is the default metrics provider
+	PrometheusProvider = "prometheus"
+	// DatadogProvider runs the queries with the Datadog metrics API
+	DatadogProvider = "datadog"
+	// InfluxDBProvider runs Flux and InfluxQL queries
+	InfluxDBProvider = "influxdb"
+	// JSONProvider runs the queries of the metric templates as HTTP requests to JSON APIs
+	JSONProvider = "json"
+)
+
+// ClientOptions holds the provider settings that are not credentials
+type ClientOptions struct {
+	// Method is the HTTP method of the json provider, can be GET or POST
+	Method string
+	// JSONPath selects the metric value in the json provider response
+	JSONPath string
+}
+
+// Client runs the metric queries of a metrics provider
+type Client interface {
+	// RenderQuery renders the builtin, custom or MetricTemplate query using the canary variables
+	RenderQuery(model MetricTemplateModel, tmpl string) (string, error)
+	// RunQuery executes the query and converts the result to float64
+	RunQuery(query string) (float64, error)
+	// RunRangeQuery executes the query over the time range and returns the samples of all the series
+	RunRangeQuery(query string, start time.Time, end time.Time, step time.Duration) ([]float64, error)
+	// IsOnline returns an error if the provider API is unreachable
+	IsOnline() (bool, error)
+	// GetMetricsServer returns the provider API address
+	GetMetricsServer() string
+}
+
+// NewClient creates the client of the metrics provider type,
+// the credentials are the data of the Secret referenced by the provider
+func NewClient(provider string, address string, credentials map[string][]byte, options ClientOptions,
+	timeout time.Duration) (Client, error) {
+	switch provider {
+	case "", PrometheusProvider:
+		client, err := NewPrometheusClientWithCredentials(address, credentials, timeout)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	case DatadogProvider:
+		client, err := NewDatadogClient(address, credentials, timeout)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	case InfluxDBProvider:
+		client, err := NewInfluxDBClient(address, credentials, timeout)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	case JSONProvider:
+		client, err := NewJSONClient(address, credentials, options, timeout)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	}
+	return nil, fmt.Errorf("metrics provider %s not supported", provider)
+}
+
+// IsSupportedProvider returns true if the metrics provider has a client implementation
+func IsSupportedProvider(provider string) bool {
+	switch provider {
+	case "", PrometheusProvider, DatadogProvider, InfluxDBProvider, JSONProvider:
+		return true
+	}
+	return false
+}
+
+// intervalClient is implemented by the clients of the providers that take
+// the query time range as an API parameter instead of a query language construct
+type intervalClient interface {
+	withInterval(interval time.Duration) Client
+}
+
+// ForInterval returns a client that runs the queries over the metric interval,
+// the clients of the providers that set the range in the query are returned as they are
+func ForInterval(client Client, interval string) (Client, error) {
+	c, ok := client.(intervalClient)
+	if !ok {
+		return client, nil
+	}
+
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return nil, fmt.Errorf("metric interval %s parse error %v", interval, err)
+	}
+	return c.withInterval(d), nil
+}
@@ -10,11 +10,12 @@ import (
 
 // Recorder records the canary analysis as Prometheus metrics
 type Recorder struct {
-	info     *prometheus.GaugeVec
-	duration *prometheus.HistogramVec
-	total    *prometheus.GaugeVec
-	status   *prometheus.GaugeVec
-	weight   *prometheus.GaugeVec
+	info      *prometheus.GaugeVec
+	duration  *prometheus.HistogramVec
+	total     *prometheus.GaugeVec
+	status    *prometheus.GaugeVec
+	weight    *prometheus.GaugeVec
+	suspended *prometheus.GaugeVec
 }
 
 // NewRecorder creates a new recorder and registers the Prometheus metrics
@@ -51,20 +52,28 @@ func NewRecorder(controller string, register bool) Recorder {
 		Help:      "The virtual service destination weight current value",
 	}, []string{"workload", "namespace"})
 
+	suspended := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: controller,
+		Name:      "canary_suspended",
+		Help:      "Set to 1 while the canary is suspended",
+	}, []string{"name", "namespace"})
+
 	if register {
 		prometheus.MustRegister(info)
 		prometheus.MustRegister(duration)
 		prometheus.MustRegister(total)
 		prometheus.MustRegister(status)
 		prometheus.MustRegister(weight)
+		prometheus.MustRegister(suspended)
 	}
 
 	return Recorder{
-		info:     info,
-		duration: duration,
-		total:    total,
-		status:   status,
-		weight:   weight,
+		info:      info,
+		duration:  duration,
+		total:     total,
+		status:    status,
+		weight:    weight,
+		suspended: suspended,
 	}
 }
 
@@ -102,3 +111,12 @@ func (cr *Recorder) SetWeight(cd *flaggerv1.Canary, primary int, canary int) {
 	cr.weight.WithLabelValues(fmt.Sprintf("%s-primary", cd.Spec.TargetRef.Name), cd.Namespace).Set(float64(primary))
 	cr.weight.WithLabelValues(cd.Spec.TargetRef.Name, cd.Namespace).Set(float64(canary))
 }
+
+// SetSuspended sets the canary suspended value to 1 if the canary is suspended or to 0 otherwise
+func (cr *Recorder) SetSuspended(cd *flaggerv1.Canary, suspended bool) {
+	value := 0
+	if suspended {
+		value = 1
+	}
+	cr.suspended.WithLabelValues(cd.Spec.TargetRef.Name, cd.Namespace).Set(float64(value))
+}
@@ -126,3 +126,19 @@ func (factory *Factory) MeshRouter(provider string) Interface {
 		}
 	}
 }
+
+// IsSupportedProvider returns true if the service mesh or ingress provider has a router implementation
+func IsSupportedProvider(provider string) bool {
+	switch {
+	case provider == "none", provider == "kubernetes", provider == "istio",
+		provider == "nginx", provider == "appmesh", provider == "linkerd", provider == "gloo":
+		return true
+	case strings.HasPrefix(provider, "smi:"), strings.HasPrefix(provider, "gloo:"):
+		return len(strings.SplitN(provider, ":", 2)[1]) > 0
+	case strings.HasPrefix(provider, "supergloo:appmesh"),
+		strings.HasPrefix(provider, "supergloo:istio"),
+		strings.HasPrefix(provider, "supergloo:linkerd"):
+		return true
+	}
+	return false
+}